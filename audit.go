@@ -0,0 +1,460 @@
+package gomirai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// 审计记录的操作类型
+const (
+	// AuditMute 禁言成员
+	AuditMute = "mute"
+	// AuditUnMute 解除成员禁言
+	AuditUnMute = "unmute"
+	// AuditKick 踢出成员
+	AuditKick = "kick"
+	// AuditMuteAll 全体禁言
+	AuditMuteAll = "muteAll"
+	// AuditUnMuteAll 解除全体禁言
+	AuditUnMuteAll = "unmuteAll"
+	// AuditMemberInfo 修改成员信息
+	AuditMemberInfo = "memberInfo"
+	// AuditRequest 响应申请事件，见 PolicyEngine
+	AuditRequest = "request"
+)
+
+// AuditEntry 审计记录
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Operator 发起操作的用户QQ号，由定时任务发起时为 Bot 的QQ号
+	Operator int64  `json:"operator"`
+	Action   string `json:"action"`
+	Group    int64  `json:"group"`
+	// Target 被操作的成员，全员操作为 0
+	Target   int64         `json:"target,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	// Detail 其他信息，如踢出消息或修改后的群名片
+	Detail string `json:"detail,omitempty"`
+	// Error 操作失败时的错误，成功时为空
+	Error string `json:"error,omitempty"`
+}
+
+// AuditQuery 审计记录查询条件，为 0 或为空的条件不作限制
+type AuditQuery struct {
+	Group    int64
+	Target   int64
+	Operator int64
+	Actions  []string
+	Since    time.Time
+	Until    time.Time
+	// Limit 最多返回的条数，返回最新的记录
+	Limit int
+}
+
+// match 判断记录是否满足查询条件
+func (q AuditQuery) match(e AuditEntry) bool {
+	switch {
+	case q.Group != 0 && e.Group != q.Group,
+		q.Target != 0 && e.Target != q.Target,
+		q.Operator != 0 && e.Operator != q.Operator,
+		!q.Since.IsZero() && e.Time.Before(q.Since),
+		!q.Until.IsZero() && e.Time.After(q.Until):
+		return false
+	}
+	if len(q.Actions) == 0 {
+		return true
+	}
+	for _, a := range q.Actions {
+		if a == e.Action {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditStore 审计记录存储
+type AuditStore interface {
+	// Append 写入一条记录
+	Append(e AuditEntry) error
+	// Query 按时间顺序返回满足条件的记录
+	Query(q AuditQuery) ([]AuditEntry, error)
+}
+
+// JSONLStore 基于文件的审计记录存储，每行一条 JSON 记录
+type JSONLStore struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// NewJSONLStore 打开审计记录文件，不存在时创建，写入时追加
+func NewJSONLStore(path string) (*JSONLStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLStore{path: path, f: f}, nil
+}
+
+// Append 写入一条记录
+func (s *JSONLStore) Append(e AuditEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(data, '\n'))
+	return err
+}
+
+// Query 按时间顺序返回满足条件的记录，无法解析的行将被跳过
+func (s *JSONLStore) Query(q AuditQuery) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var list []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || !q.match(e) {
+			continue
+		}
+		list = append(list, e)
+		if q.Limit > 0 && len(list) > q.Limit {
+			list = list[1:]
+		}
+	}
+	return list, scanner.Err()
+}
+
+// Close 关闭文件
+func (s *JSONLStore) Close() error {
+	return s.f.Close()
+}
+
+// ScheduledTask 定时撤销任务
+type ScheduledTask struct {
+	ID     int64     `json:"id"`
+	At     time.Time `json:"at"`
+	Action string    `json:"action"`
+	Group  int64     `json:"group"`
+	Target int64     `json:"target,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	timer  *time.Timer
+}
+
+// Auditor 记录管理操作的审计日志，并支持定时撤销
+// 所有操作均记录操作者、对象、群、原因、时长及结果，记录写入失败不影响操作本身
+type Auditor struct {
+	Bot   *Bot
+	Store AuditStore
+
+	mu        sync.Mutex
+	tasks     map[int64]*ScheduledTask
+	nextID    int64
+	statePath string
+}
+
+// NewAuditor 新建审计
+func NewAuditor(bot *Bot, store AuditStore) *Auditor {
+	return &Auditor{Bot: bot, Store: store, tasks: make(map[int64]*ScheduledTask)}
+}
+
+// record 执行操作并写入审计记录
+func (a *Auditor) record(e AuditEntry, err error) error {
+	e.Time = time.Now()
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if werr := a.Store.Append(e); werr != nil {
+		a.Bot.Logger.Errorln("Write Audit Failed:", werr)
+	}
+	a.Bot.Logger.Infoln("Audit:", e.Action, "group:", e.Group, "target:", e.Target, "operator:", e.Operator, "result:", errString(err))
+	return err
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return err.Error()
+}
+
+// Mute 禁言群成员并记录
+func (a *Auditor) Mute(operator, group, target int64, d time.Duration, reason string) error {
+	return a.MuteContext(context.Background(), operator, group, target, d, reason)
+}
+
+// MuteContext 同 Mute
+func (a *Auditor) MuteContext(ctx context.Context, operator, group, target int64, d time.Duration, reason string) error {
+	err := a.Bot.MuteContext(ctx, group, target, int64(d/time.Second))
+	return a.record(AuditEntry{Operator: operator, Action: AuditMute, Group: group, Target: target, Reason: reason, Duration: d}, err)
+}
+
+// UnMute 解除群成员禁言并记录
+func (a *Auditor) UnMute(operator, group, target int64, reason string) error {
+	return a.UnMuteContext(context.Background(), operator, group, target, reason)
+}
+
+// UnMuteContext 同 UnMute
+func (a *Auditor) UnMuteContext(ctx context.Context, operator, group, target int64, reason string) error {
+	err := a.Bot.UnMuteContext(ctx, group, target)
+	return a.record(AuditEntry{Operator: operator, Action: AuditUnMute, Group: group, Target: target, Reason: reason}, err)
+}
+
+// Kick 移除群成员并记录，msg 为踢出时附带的消息
+func (a *Auditor) Kick(operator, group, target int64, msg, reason string) error {
+	return a.KickContext(context.Background(), operator, group, target, msg, reason)
+}
+
+// KickContext 同 Kick
+func (a *Auditor) KickContext(ctx context.Context, operator, group, target int64, msg, reason string) error {
+	err := a.Bot.KickContext(ctx, group, target, msg)
+	return a.record(AuditEntry{Operator: operator, Action: AuditKick, Group: group, Target: target, Reason: reason, Detail: msg}, err)
+}
+
+// MuteAll 全体禁言并记录，d 大于 0 时在 d 后自动解除
+func (a *Auditor) MuteAll(operator, group int64, d time.Duration, reason string) error {
+	return a.MuteAllContext(context.Background(), operator, group, d, reason)
+}
+
+// MuteAllContext 同 MuteAll
+func (a *Auditor) MuteAllContext(ctx context.Context, operator, group int64, d time.Duration, reason string) error {
+	err := a.Bot.MuteAllContext(ctx, group)
+	err = a.record(AuditEntry{Operator: operator, Action: AuditMuteAll, Group: group, Reason: reason, Duration: d}, err)
+	if err == nil && d > 0 {
+		a.Schedule(time.Now().Add(d), AuditUnMuteAll, group, 0, reason)
+	}
+	return err
+}
+
+// UnMuteAll 解除全体禁言并记录
+func (a *Auditor) UnMuteAll(operator, group int64, reason string) error {
+	return a.UnMuteAllContext(context.Background(), operator, group, reason)
+}
+
+// UnMuteAllContext 同 UnMuteAll
+func (a *Auditor) UnMuteAllContext(ctx context.Context, operator, group int64, reason string) error {
+	err := a.Bot.UnMuteAllContext(ctx, group)
+	return a.record(AuditEntry{Operator: operator, Action: AuditUnMuteAll, Group: group, Reason: reason}, err)
+}
+
+// MemberInfo 修改群员资料并记录
+func (a *Auditor) MemberInfo(operator, group, target int64, info message.MemberInfo, reason string) error {
+	return a.MemberInfoContext(context.Background(), operator, group, target, info, reason)
+}
+
+// MemberInfoContext 同 MemberInfo
+func (a *Auditor) MemberInfoContext(ctx context.Context, operator, group, target int64, info message.MemberInfo, reason string) error {
+	err := a.Bot.MemberInfoContext(ctx, group, target, info)
+	detail := fmt.Sprintf("name=%q specialTitle=%q", info.Name, info.SpecialTitle)
+	return a.record(AuditEntry{Operator: operator, Action: AuditMemberInfo, Group: group, Target: target, Reason: reason, Detail: detail}, err)
+}
+
+// Query 查询审计记录
+func (a *Auditor) Query(q AuditQuery) ([]AuditEntry, error) {
+	return a.Store.Query(q)
+}
+
+// --- 定时撤销 ---
+
+// SetScheduleFile 设置保存定时任务的文件，文件中已有的任务将重新计时，已过期的任务立即执行
+func (a *Auditor) SetScheduleFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var list []*ScheduledTask
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return err
+		}
+	}
+	a.mu.Lock()
+	a.statePath = path
+	for _, t := range list {
+		if t.ID > a.nextID {
+			a.nextID = t.ID
+		}
+		a.start(t)
+	}
+	a.mu.Unlock()
+	a.save()
+	return nil
+}
+
+// Schedule 在 at 时执行撤销操作，action 为 AuditUnMute 或 AuditUnMuteAll，返回任务id
+func (a *Auditor) Schedule(at time.Time, action string, group, target int64, reason string) (int64, error) {
+	if action != AuditUnMute && action != AuditUnMuteAll {
+		return 0, errors.New("不支持定时执行的操作: " + action)
+	}
+	a.mu.Lock()
+	a.nextID++
+	t := &ScheduledTask{ID: a.nextID, At: at, Action: action, Group: group, Target: target, Reason: reason}
+	a.start(t)
+	a.mu.Unlock()
+	a.save()
+	return t.ID, nil
+}
+
+// Cancel 取消定时任务，返回任务是否存在
+func (a *Auditor) Cancel(id int64) bool {
+	a.mu.Lock()
+	t, ok := a.tasks[id]
+	if ok {
+		t.timer.Stop()
+		delete(a.tasks, id)
+	}
+	a.mu.Unlock()
+	if ok {
+		a.save()
+	}
+	return ok
+}
+
+// Scheduled 尚未执行的定时任务
+func (a *Auditor) Scheduled() []ScheduledTask {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]ScheduledTask, 0, len(a.tasks))
+	for _, t := range a.tasks {
+		list = append(list, *t)
+	}
+	return list
+}
+
+// start 开始计时，调用时需持有锁
+func (a *Auditor) start(t *ScheduledTask) {
+	a.tasks[t.ID] = t
+	t.timer = time.AfterFunc(time.Until(t.At), func() { a.run(t) })
+}
+
+// run 执行定时任务
+func (a *Auditor) run(t *ScheduledTask) {
+	a.mu.Lock()
+	if a.tasks[t.ID] != t {
+		a.mu.Unlock()
+		return
+	}
+	delete(a.tasks, t.ID)
+	a.mu.Unlock()
+	a.save()
+
+	reason := "定时撤销"
+	if t.Reason != "" {
+		reason += ": " + t.Reason
+	}
+	switch t.Action {
+	case AuditUnMute:
+		a.UnMute(a.Bot.QQ, t.Group, t.Target, reason)
+	case AuditUnMuteAll:
+		a.UnMuteAll(a.Bot.QQ, t.Group, reason)
+	}
+}
+
+// save 保存定时任务
+func (a *Auditor) save() {
+	a.mu.Lock()
+	path := a.statePath
+	list := make([]*ScheduledTask, 0, len(a.tasks))
+	for _, t := range a.tasks {
+		list = append(list, t)
+	}
+	a.mu.Unlock()
+	if path == "" {
+		return
+	}
+	if err := writeJSONAtomic(path, list); err != nil {
+		a.Bot.Logger.Errorln("Save Schedule Failed:", err)
+	}
+}
+
+// writeJSONAtomic 将 v 序列化为 JSON 写入临时文件后重命名为 path，避免写入中断时损坏原文件
+func writeJSONAtomic(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// --- 查询命令 ---
+
+// Command 供管理员查询本群审计记录的命令，用法 "audit [@成员] [条数]"
+// 默认仅群主及管理员可用，可通过返回值的 Guard 修改
+func (a *Auditor) Command() *Command {
+	return &Command{
+		Name:        "audit",
+		Usage:       "[@成员] [条数]",
+		Description: "查询本群的管理记录",
+		Guard:       &Guard{MinRole: message.ADMINISTRATOR},
+		Handler: func(c *CommandContext) error {
+			q := AuditQuery{Group: c.Sender().Group.ID, Limit: 10}
+			for _, arg := range c.Args {
+				if arg.AtQQ != 0 {
+					q.Target = arg.AtQQ
+					continue
+				}
+				n, err := arg.Int()
+				if err != nil || n <= 0 {
+					return ErrUsage
+				}
+				q.Limit = int(n)
+			}
+			list, err := a.Query(q)
+			if err != nil {
+				return err
+			}
+			if len(list) == 0 {
+				_, err = c.ReplyText("暂无记录")
+				return err
+			}
+			lines := make([]string, 0, len(list))
+			for i := len(list) - 1; i >= 0; i-- {
+				lines = append(lines, list[i].String())
+			}
+			_, err = c.ReplyText(strings.Join(lines, "\n"))
+			return err
+		},
+	}
+}
+
+// String 单行文本表示
+func (e AuditEntry) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %d %s", e.Time.Format("01-02 15:04"), e.Operator, e.Action)
+	if e.Target != 0 {
+		fmt.Fprintf(&sb, " %d", e.Target)
+	}
+	if e.Duration > 0 {
+		fmt.Fprintf(&sb, " %s", e.Duration)
+	}
+	if e.Reason != "" {
+		sb.WriteString(" " + e.Reason)
+	}
+	if e.Error != "" {
+		sb.WriteString(" 失败: " + e.Error)
+	}
+	return sb.String()
+}
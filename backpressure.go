@@ -0,0 +1,198 @@
+package gomirai
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// Chan 已满时的处理策略
+const (
+	// OverflowDropOldest 丢弃最早的事件（默认）
+	OverflowDropOldest = iota
+	// OverflowDropNewest 丢弃新收到的事件
+	OverflowDropNewest
+	// OverflowBlock 阻塞直至 Chan 有空位，期间暂停接收
+	OverflowBlock
+	// OverflowSpill 将事件暂存至磁盘文件，Chan 有空位时按顺序取回
+	OverflowSpill
+)
+
+// SetFetchCount 设置每次轮询获取的消息数量，与 Chan 的容量无关
+func (b *Bot) SetFetchCount(count int) {
+	b.fetchCount = count
+}
+
+// SetOverflowPolicy 设置 Chan 已满时的处理策略
+// 使用 OverflowSpill 时需通过 SetSpillFile 设置暂存文件
+func (b *Bot) SetOverflowPolicy(policy int) {
+	b.overflow = policy
+}
+
+// SetSpillFile 使用 OverflowSpill 策略并设置暂存文件，文件中原有内容将被清空
+func (b *Bot) SetSpillFile(path string) error {
+	q, err := newSpillQueue(path)
+	if err != nil {
+		return err
+	}
+	b.spill = q
+	b.overflow = OverflowSpill
+	go b.drainSpill()
+	return nil
+}
+
+// Dropped 因 Chan 已满而丢弃的事件数量
+func (b *Bot) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// drop 记录被丢弃的事件
+func (b *Bot) drop(e message.Event) {
+	n := atomic.AddUint64(&b.dropped, 1)
+	b.Logger.Warnln("Channel Full, Event Dropped:", e.EventType(), "Total Dropped:", n)
+}
+
+// enqueue 按照策略将事件放入 Chan
+func (b *Bot) enqueue(e message.Event) {
+	switch b.overflow {
+	case OverflowBlock:
+		b.Chan <- e
+	case OverflowDropNewest:
+		select {
+		case b.Chan <- e:
+		default:
+			b.drop(e)
+		}
+	case OverflowSpill:
+		if b.spill != nil {
+			// 暂存文件中仍有事件时直接写入文件以保持顺序
+			if b.spill.Len() == 0 {
+				select {
+				case b.Chan <- e:
+					return
+				default:
+				}
+			}
+			if err := b.spill.Push(e); err != nil {
+				b.Logger.Errorln("Spill Event Failed:", err)
+				b.drop(e)
+			}
+			return
+		}
+		fallthrough
+	default:
+		for {
+			select {
+			case b.Chan <- e:
+				return
+			default:
+			}
+			select {
+			case old := <-b.Chan:
+				b.drop(old)
+			default:
+			}
+		}
+	}
+}
+
+// drainSpill 将暂存的事件按顺序放回 Chan
+func (b *Bot) drainSpill() {
+	for range b.spill.notify {
+		for {
+			e, ok, err := b.spill.Peek()
+			if err != nil {
+				b.Logger.Errorln("Read Spilled Event Failed:", err)
+			}
+			if !ok {
+				break
+			}
+			if e != nil {
+				b.Chan <- e
+			}
+			b.spill.Done()
+		}
+	}
+}
+
+// spillQueue 基于文件的事件队列，每行一个 JSON 事件
+type spillQueue struct {
+	mu      sync.Mutex
+	w       *os.File
+	r       *os.File
+	reader  *bufio.Reader
+	pending int
+	notify  chan struct{}
+}
+
+func newSpillQueue(path string) (*spillQueue, error) {
+	w, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(path)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	return &spillQueue{w: w, r: r, reader: bufio.NewReader(r), notify: make(chan struct{}, 1)}, nil
+}
+
+// Len 尚未取回的事件数量
+func (q *spillQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending
+}
+
+// Push 写入事件
+func (q *spillQueue) Push(e message.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, err := q.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	q.pending++
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Peek 读取下一个事件，处理完毕后需调用 Done
+// 队列为空时 ok 为 false；事件无法解析时 e 为 nil
+func (q *spillQueue) Peek() (e message.Event, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == 0 {
+		return nil, false, nil
+	}
+	line, err := q.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, true, err
+	}
+	e, err = message.DecodeEvent(line)
+	return e, true, err
+}
+
+// Done 标记上一个事件已处理，队列清空时截断文件
+func (q *spillQueue) Done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending--; q.pending > 0 {
+		return
+	}
+	q.pending = 0
+	q.w.Truncate(0)
+	q.r.Seek(0, 0)
+	q.reader.Reset(q.r)
+}
@@ -1,10 +1,13 @@
 package gomirai
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,30 +18,55 @@ import (
 
 // Bot 对应一个机器人账号
 // 进行所有对账号相关操作
+// 所有请求方法均有对应的 *Context 方法，可通过 ctx 取消请求
 type Bot struct {
+	// dropped 需保持64位对齐以使用 atomic，置于首位
+	dropped     uint64
 	QQ          int64
 	SessionKey  string
+	sessionMu   sync.RWMutex
+	recoverMu   sync.Mutex
 	Client      *Client
 	Logger      *logrus.Entry
 	fetchTime   time.Duration
 	size        int
 	currentSize int
-	Chan        chan message.ComplexEvent
+	Chan        chan message.Event
 	Friends     []message.Friend
 	Groups      []message.Group
 	handlers    EventHandler
+	wsEndpoint  string
+	waiters     waiters
+	workers     int
+	queueSize   int
+	fetchCount  int
+	overflow    int
+	spill       *spillQueue
+	bus         eventBus
+	webhook     webhookConfig
+	heartbeat   heartbeatState
+	runCtx      atomic.Value
 }
 
 // --- Bot 设置 ---
 
 // SetChannel Channel相关设置
+// time 为轮询间隔，size 为 Chan 的容量，未通过 SetFetchCount 设置时同时作为每次获取的消息数量
 func (b *Bot) SetChannel(time time.Duration, size int) {
-	b.Chan = make(chan message.ComplexEvent, size)
+	b.Chan = make(chan message.Event, size)
 	b.size = size
 	b.currentSize = 0
 	b.fetchTime = time
 }
 
+// SetWorkers 设置 Run 使用的并发处理数
+// workers 大于 1 时 Run 使用 WorkerPool 并发处理事件，同一群或好友的事件仍按顺序处理
+// queueSize 为每个 worker 的队列长度
+func (b *Bot) SetWorkers(workers, queueSize int) {
+	b.workers = workers
+	b.queueSize = queueSize
+}
+
 // --- 消息相关 ---
 
 // SendFriendMessage 使用此方法向指定好友发送消息
@@ -46,11 +74,16 @@ func (b *Bot) SetChannel(time time.Duration, size int) {
 // quote 引用消息id 0为不引用
 // msg 消息内容
 func (b *Bot) SendFriendMessage(qq, quote int64, msg ...message.Message) (int64, error) {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "qq": qq, "messageChain": msg}
+	return b.SendFriendMessageContext(context.Background(), qq, quote, msg...)
+}
+
+// SendFriendMessageContext 同 SendFriendMessage
+func (b *Bot) SendFriendMessageContext(ctx context.Context, qq, quote int64, msg ...message.Message) (int64, error) {
+	data := map[string]interface{}{"qq": qq, "messageChain": msg}
 	if quote != 0 {
 		data["quote"] = quote
 	}
-	res, err := b.Client.doPost("/sendFriendMessage", data)
+	res, err := b.post(ctx, "/sendFriendMessage", data)
 	if err != nil {
 		return 0, err
 	}
@@ -63,8 +96,13 @@ func (b *Bot) SendFriendMessage(qq, quote int64, msg ...message.Message) (int64,
 // group 群qq
 // msg 消息内容
 func (b *Bot) SendTempMessage(group, qq int64, msg ...message.Message) (int64, error) {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "qq": qq, "group": group, "messageChain": msg}
-	res, err := b.Client.doPost("/sendTempMessage", data)
+	return b.SendTempMessageContext(context.Background(), group, qq, msg...)
+}
+
+// SendTempMessageContext 同 SendTempMessage
+func (b *Bot) SendTempMessageContext(ctx context.Context, group, qq int64, msg ...message.Message) (int64, error) {
+	data := map[string]interface{}{"qq": qq, "group": group, "messageChain": msg}
+	res, err := b.post(ctx, "/sendTempMessage", data)
 	if err != nil {
 		return 0, err
 	}
@@ -77,11 +115,16 @@ func (b *Bot) SendTempMessage(group, qq int64, msg ...message.Message) (int64, e
 // quote 引用消息id 0为不引用
 // msg 消息内容
 func (b *Bot) SendGroupMessage(group, quote int64, msg ...message.Message) (int64, error) {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "group": group, "messageChain": msg}
+	return b.SendGroupMessageContext(context.Background(), group, quote, msg...)
+}
+
+// SendGroupMessageContext 同 SendGroupMessage
+func (b *Bot) SendGroupMessageContext(ctx context.Context, group, quote int64, msg ...message.Message) (int64, error) {
+	data := map[string]interface{}{"group": group, "messageChain": msg}
 	if quote != 0 {
 		data["quote"] = quote
 	}
-	res, err := b.Client.doPost("/sendGroupMessage", data)
+	res, err := b.post(ctx, "/sendGroupMessage", data)
 	if err != nil {
 		return 0, err
 	}
@@ -93,16 +136,21 @@ func (b *Bot) SendGroupMessage(group, quote int64, msg ...message.Message) (int6
 // 除非需要通过此手段获取imageId，否则不推荐使用该接口
 // 请保证 qq group 不同时有值
 func (b *Bot) SendImageMessage(qq, group int64, urls ...string) (imageIds []string, err error) {
+	return b.SendImageMessageContext(context.Background(), qq, group, urls...)
+}
+
+// SendImageMessageContext 同 SendImageMessage
+func (b *Bot) SendImageMessageContext(ctx context.Context, qq, group int64, urls ...string) (imageIds []string, err error) {
 	if qq*group == 0 {
 		return nil, errors.New("非法参数")
 	}
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "urls": urls}
+	data := map[string]interface{}{"urls": urls}
 	if qq == 0 {
 		data["group"] = group
 	} else {
 		data["qq"] = qq
 	}
-	res, err := b.Client.doPost("sendImageMessage", data)
+	res, err := b.post(ctx, "sendImageMessage", data)
 	if err != nil {
 		return nil, err
 	}
@@ -116,14 +164,19 @@ func (b *Bot) SendImageMessage(qq, group int64, urls ...string) (imageIds []stri
 
 // UploadImage 使用此方法上传图片文件至服务器并返回ImageId
 func (b *Bot) UploadImage(t string, imgFilepath string) (string, error) {
+	return b.UploadImageContext(context.Background(), t, imgFilepath)
+}
+
+// UploadImageContext 同 UploadImage
+func (b *Bot) UploadImageContext(ctx context.Context, t string, imgFilepath string) (string, error) {
 	imgReader, err := os.Open(imgFilepath)
 	if err != nil {
 		return "", err
 	}
 	defer imgReader.Close()
 
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "type": t, "img": imgReader}
-	res, err := b.Client.doPostWithFormData("/uploadImage", data)
+	data := map[string]interface{}{"type": t, "img": imgReader}
+	res, err := b.postForm(ctx, "/uploadImage", data)
 	if err != nil {
 		return "", err
 	}
@@ -135,48 +188,96 @@ func (b *Bot) UploadImage(t string, imgFilepath string) (string, error) {
 // 对于bot发送的消息，有2分钟时间限制。对于撤回群聊中群员的消息，需要有相应权限
 // target 消息id
 func (b *Bot) Recall(target int64) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target}
-	_, err := b.Client.doPost("/recall", data)
+	return b.RecallContext(context.Background(), target)
+}
+
+// RecallContext 同 Recall
+func (b *Bot) RecallContext(ctx context.Context, target int64) error {
+	data := map[string]interface{}{"target": target}
+	_, err := b.post(ctx, "/recall", data)
 	return err
 }
 
 // FetchMessages 获取消息
 func (b *Bot) FetchMessages() error {
+	return b.FetchMessagesContext(context.Background())
+}
+
+// FetchMessagesContext 获取消息，ctx 取消时返回 nil
+func (b *Bot) FetchMessagesContext(ctx context.Context) error {
 	t := time.NewTicker(b.fetchTime)
+	defer t.Stop()
 
 	for {
-		res, err := b.Client.doGet("/fetchMessage", map[string]string{
-			"sessionKey": b.SessionKey,
-			"count":      strconv.Itoa(b.size),
-		})
+		count := b.fetchCount
+		if count <= 0 {
+			count = b.size
+		}
+		res, err := b.get(ctx, "/fetchMessage", map[string]string{"count": strconv.Itoa(count)})
+		if ctx.Err() != nil {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
-		events := gjson.Get(res, "data").Array()
-		for _, event := range events {
-			if event.Get("type").String() == message.EventGroupMuteAll {
-				continue
-			}
-			var c message.ComplexEvent
-			if err := json.Unmarshal([]byte(event.Raw), &c); err != nil {
-				b.Logger.Errorln("Unmarshal Event", err)
-				continue
-			}
-			if len(b.Chan) == b.size {
-				<-b.Chan
-			}
-			b.Chan <- c
+		for _, event := range gjson.Get(res, "data").Array() {
+			b.pushEvent(event)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
 		}
-		<-t.C
 	}
 }
 
+// Listen 按照设置的接收方式获取事件
+// 使用上报时等待 WebhookHandler 接收事件，设置了 websocket 时使用 ListenWebSocket，否则使用 FetchMessages 轮询
+func (b *Bot) Listen() error {
+	return b.ListenContext(context.Background())
+}
+
+// ListenContext 同 Listen，ctx 取消时返回 nil
+func (b *Bot) ListenContext(ctx context.Context) error {
+	if b.webhook.enabled {
+		<-ctx.Done()
+		return nil
+	}
+	if b.wsEndpoint != "" {
+		return b.ListenWebSocketContext(ctx)
+	}
+	return b.FetchMessagesContext(ctx)
+}
+
+// pushEvent 解析事件并放入 Chan
+func (b *Bot) pushEvent(event gjson.Result) {
+	c, err := message.DecodeEvent([]byte(event.Raw))
+	if err != nil {
+		b.Logger.Errorln("Unmarshal Event", err)
+		return
+	}
+	b.receive(c)
+}
+
+// receive 将事件交给订阅者、等待者，最后放入 Chan
+func (b *Bot) receive(e message.Event) {
+	b.publish(e)
+	if b.intercept(e) {
+		return
+	}
+	b.enqueue(e)
+}
+
 // --- 管理相关 ---
 
 // FriendList 使用此方法获取bot的好友列表
 func (b *Bot) FriendList() error {
-	data := map[string]string{"sessionKey": b.SessionKey}
-	res, err := b.Client.doGet("/friendList", data)
+	return b.FriendListContext(context.Background())
+}
+
+// FriendListContext 同 FriendList
+func (b *Bot) FriendListContext(ctx context.Context) error {
+	res, err := b.get(ctx, "/friendList", nil)
 	if err != nil {
 		return err
 	}
@@ -185,8 +286,12 @@ func (b *Bot) FriendList() error {
 
 // GroupList 使用此方法获取bot的群列表
 func (b *Bot) GroupList() error {
-	data := map[string]string{"sessionKey": b.SessionKey}
-	res, err := b.Client.doGet("/groupList", data)
+	return b.GroupListContext(context.Background())
+}
+
+// GroupListContext 同 GroupList
+func (b *Bot) GroupListContext(ctx context.Context) error {
+	res, err := b.get(ctx, "/groupList", nil)
 	if err != nil {
 		return err
 	}
@@ -195,8 +300,13 @@ func (b *Bot) GroupList() error {
 
 // MemberList 使用此方法获取bot指定群种的成员列表
 func (b *Bot) MemberList(target int64) ([]message.Sender, error) {
-	data := map[string]string{"sessionKey": b.SessionKey, "target": strconv.FormatInt(target, 10)}
-	res, err := b.Client.doGet("/memberList", data)
+	return b.MemberListContext(context.Background(), target)
+}
+
+// MemberListContext 同 MemberList
+func (b *Bot) MemberListContext(ctx context.Context, target int64) ([]message.Sender, error) {
+	data := map[string]string{"target": strconv.FormatInt(target, 10)}
+	res, err := b.get(ctx, "/memberList", data)
 	if err != nil {
 		return nil, err
 	}
@@ -207,58 +317,98 @@ func (b *Bot) MemberList(target int64) ([]message.Sender, error) {
 
 // MuteAll 使用此方法令指定群进行全体禁言（需要有相关限权）
 func (b *Bot) MuteAll(target int64) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target}
-	_, err := b.Client.doPost("/muteAll", data)
+	return b.MuteAllContext(context.Background(), target)
+}
+
+// MuteAllContext 同 MuteAll
+func (b *Bot) MuteAllContext(ctx context.Context, target int64) error {
+	data := map[string]interface{}{"target": target}
+	_, err := b.post(ctx, "/muteAll", data)
 	return err
 }
 
 // UnMuteAll 使用此方法令指定群解除全体禁言（需要有相关限权）
 func (b *Bot) UnMuteAll(target int64) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target}
-	_, err := b.Client.doPost("/unmuteAll", data)
+	return b.UnMuteAllContext(context.Background(), target)
+}
+
+// UnMuteAllContext 同 UnMuteAll
+func (b *Bot) UnMuteAllContext(ctx context.Context, target int64) error {
+	data := map[string]interface{}{"target": target}
+	_, err := b.post(ctx, "/unmuteAll", data)
 	return err
 }
 
 // Mute 使用此方法指定群禁言指定群员（需要有相关限权）
 func (b *Bot) Mute(target, memberID, time int64) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target, "memberId": memberID, "time": time}
-	_, err := b.Client.doPost("/mute", data)
+	return b.MuteContext(context.Background(), target, memberID, time)
+}
+
+// MuteContext 同 Mute
+func (b *Bot) MuteContext(ctx context.Context, target, memberID, time int64) error {
+	data := map[string]interface{}{"target": target, "memberId": memberID, "time": time}
+	_, err := b.post(ctx, "/mute", data)
 	return err
 }
 
 // UnMute 使用此方法指定群解除群成员禁言（需要有相关限权）
 func (b *Bot) UnMute(target, memberID int64) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target, "memberId": memberID}
-	_, err := b.Client.doPost("/unmute", data)
+	return b.UnMuteContext(context.Background(), target, memberID)
+}
+
+// UnMuteContext 同 UnMute
+func (b *Bot) UnMuteContext(ctx context.Context, target, memberID int64) error {
+	data := map[string]interface{}{"target": target, "memberId": memberID}
+	_, err := b.post(ctx, "/unmute", data)
 	return err
 }
 
 // Kick 使用此方法移除指定群成员（需要有相关限权）
 func (b *Bot) Kick(target, memberID int64, msg string) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target, "memberId": memberID, "msg": msg}
-	_, err := b.Client.doPost("/kick", data)
+	return b.KickContext(context.Background(), target, memberID, msg)
+}
+
+// KickContext 同 Kick
+func (b *Bot) KickContext(ctx context.Context, target, memberID int64, msg string) error {
+	data := map[string]interface{}{"target": target, "memberId": memberID, "msg": msg}
+	_, err := b.post(ctx, "/kick", data)
 	return err
 }
 
 // Quit 使用此方法使Bot退出群聊
 func (b *Bot) Quit(target int64) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target}
-	_, err := b.Client.doPost("/quit", data)
+	return b.QuitContext(context.Background(), target)
+}
+
+// QuitContext 同 Quit
+func (b *Bot) QuitContext(ctx context.Context, target int64) error {
+	data := map[string]interface{}{"target": target}
+	_, err := b.post(ctx, "/quit", data)
 	return err
 }
 
 // GroupConfig 使用此方法修改群设置（需要有相关限权）
 func (b *Bot) GroupConfig(target int64, config message.GroupConfig) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target, "config": config}
-	_, err := b.Client.doPost("/groupConfig", data)
+	return b.GroupConfigContext(context.Background(), target, config)
+}
+
+// GroupConfigContext 同 GroupConfig
+func (b *Bot) GroupConfigContext(ctx context.Context, target int64, config message.GroupConfig) error {
+	data := map[string]interface{}{"target": target, "config": config}
+	_, err := b.post(ctx, "/groupConfig", data)
 	return err
 }
 
 // GetGroupConfig 使用此方法获取群设置
 func (b *Bot) GetGroupConfig(target int64) (message.GroupConfig, error) {
+	return b.GetGroupConfigContext(context.Background(), target)
+}
+
+// GetGroupConfigContext 同 GetGroupConfig
+func (b *Bot) GetGroupConfigContext(ctx context.Context, target int64) (message.GroupConfig, error) {
 	r := message.GroupConfig{}
-	data := map[string]string{"sessionKey": b.SessionKey, "target": strconv.FormatInt(target, 10)}
-	res, err := b.Client.doGet("/groupConfig", data)
+	data := map[string]string{"target": strconv.FormatInt(target, 10)}
+	res, err := b.get(ctx, "/groupConfig", data)
 	if err != nil {
 		return r, err
 	}
@@ -268,16 +418,26 @@ func (b *Bot) GetGroupConfig(target int64) (message.GroupConfig, error) {
 
 // MemberInfo 使用此方法修改群员资料（需要有相关限权）
 func (b *Bot) MemberInfo(target, memberID int64, info message.MemberInfo) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "target": target, "memberId": memberID, "info": info}
-	_, err := b.Client.doPost("/memberInfo", data)
+	return b.MemberInfoContext(context.Background(), target, memberID, info)
+}
+
+// MemberInfoContext 同 MemberInfo
+func (b *Bot) MemberInfoContext(ctx context.Context, target, memberID int64, info message.MemberInfo) error {
+	data := map[string]interface{}{"target": target, "memberId": memberID, "info": info}
+	_, err := b.post(ctx, "/memberInfo", data)
 	return err
 }
 
 // GetMemberInfo 使用此方法获取群员资料
 func (b *Bot) GetMemberInfo(target, memberID int64) (message.MemberInfo, error) {
+	return b.GetMemberInfoContext(context.Background(), target, memberID)
+}
+
+// GetMemberInfoContext 同 GetMemberInfo
+func (b *Bot) GetMemberInfoContext(ctx context.Context, target, memberID int64) (message.MemberInfo, error) {
 	r := message.MemberInfo{}
-	data := map[string]string{"sessionKey": b.SessionKey, "target": strconv.FormatInt(target, 10), "memberId": strconv.FormatInt(memberID, 10)}
-	res, err := b.Client.doGet("/groupConfig", data)
+	data := map[string]string{"target": strconv.FormatInt(target, 10), "memberId": strconv.FormatInt(memberID, 10)}
+	res, err := b.get(ctx, "/groupConfig", data)
 	if err != nil {
 		return r, err
 	}
@@ -308,8 +468,13 @@ const (
 // 3	拒绝入群并添加黑名单，不再接收该用户的入群申请
 // 4	忽略入群并添加黑名单，不再接收该用户的入群申请
 func (b *Bot) RespondMemberJoinRequest(eventID, fromID, groupID int64, operate int, message string) error {
-	data := map[string]interface{}{"sessionKey": b.SessionKey, "eventId": eventID, "fromId": fromID, "groupId": groupID, "operate": operate, "message": message}
-	_, err := b.Client.doPost("/resp/memberJoinRequestEvent", data)
+	return b.RespondMemberJoinRequestContext(context.Background(), eventID, fromID, groupID, operate, message)
+}
+
+// RespondMemberJoinRequestContext 同 RespondMemberJoinRequest
+func (b *Bot) RespondMemberJoinRequestContext(ctx context.Context, eventID, fromID, groupID int64, operate int, message string) error {
+	data := map[string]interface{}{"eventId": eventID, "fromId": fromID, "groupId": groupID, "operate": operate, "message": message}
+	_, err := b.post(ctx, "/resp/memberJoinRequestEvent", data)
 	if err != nil {
 		return err
 	}
@@ -317,29 +482,132 @@ func (b *Bot) RespondMemberJoinRequest(eventID, fromID, groupID int64, operate i
 	return nil
 }
 
+// FriendOperate 响应添加好友申请的操作
+type FriendOperate int
+
+const (
+	// FriendAgree 同意添加好友
+	FriendAgree FriendOperate = iota
+	// FriendRefuse 拒绝添加好友
+	FriendRefuse
+	// FriendRefuseBan 拒绝添加好友并添加黑名单，不再接收该用户的好友申请
+	FriendRefuseBan
+)
+
+// RespondNewFriendRequest 响应添加好友申请
+func (b *Bot) RespondNewFriendRequest(eventID, fromID, groupID int64, operate FriendOperate, message string) error {
+	return b.RespondNewFriendRequestContext(context.Background(), eventID, fromID, groupID, operate, message)
+}
+
+// RespondNewFriendRequestContext 同 RespondNewFriendRequest
+func (b *Bot) RespondNewFriendRequestContext(ctx context.Context, eventID, fromID, groupID int64, operate FriendOperate, message string) error {
+	data := map[string]interface{}{"eventId": eventID, "fromId": fromID, "groupId": groupID, "operate": int(operate), "message": message}
+	_, err := b.post(ctx, "/resp/newFriendRequestEvent", data)
+	if err != nil {
+		return err
+	}
+	b.Logger.Info("Respond New Friend Request ", fromID, " operate: ", operate)
+	return nil
+}
+
+// InviteOperate 响应Bot被邀请入群申请的操作
+type InviteOperate int
+
+const (
+	// InviteAgree 同意邀请
+	InviteAgree InviteOperate = iota
+	// InviteRefuse 拒绝邀请
+	InviteRefuse
+)
+
+// RespondBotInvitedJoinGroupRequest 响应Bot被邀请入群申请
+func (b *Bot) RespondBotInvitedJoinGroupRequest(eventID, fromID, groupID int64, operate InviteOperate, message string) error {
+	return b.RespondBotInvitedJoinGroupRequestContext(context.Background(), eventID, fromID, groupID, operate, message)
+}
+
+// RespondBotInvitedJoinGroupRequestContext 同 RespondBotInvitedJoinGroupRequest
+func (b *Bot) RespondBotInvitedJoinGroupRequestContext(ctx context.Context, eventID, fromID, groupID int64, operate InviteOperate, message string) error {
+	data := map[string]interface{}{"eventId": eventID, "fromId": fromID, "groupId": groupID, "operate": int(operate), "message": message}
+	_, err := b.post(ctx, "/resp/botInvitedJoinGroupRequestEvent", data)
+	if err != nil {
+		return err
+	}
+	b.Logger.Info("Respond Bot Invited Join Group Request ", fromID, " invite ", groupID, " operate: ", operate)
+	return nil
+}
+
+// RespondRequest 以同意或拒绝响应申请事件，实现 message.RequestResponder
+func (b *Bot) RespondRequest(e message.Event, approve bool, msg string) error {
+	switch e := e.(type) {
+	case *message.NewFriendRequestEvent:
+		op := FriendRefuse
+		if approve {
+			op = FriendAgree
+		}
+		return b.RespondNewFriendRequest(e.EventID, e.FromID, e.GroupID, op, msg)
+	case *message.MemberJoinRequestEvent:
+		op := OperateRefuse
+		if approve {
+			op = OperateAgree
+		}
+		return b.RespondMemberJoinRequest(e.EventID, e.FromID, e.GroupID, op, msg)
+	case *message.BotInvitedJoinGroupRequestEvent:
+		op := InviteRefuse
+		if approve {
+			op = InviteAgree
+		}
+		return b.RespondBotInvitedJoinGroupRequest(e.EventID, e.FromID, e.GroupID, op, msg)
+	}
+	return errors.New("非申请事件: " + e.EventType())
+}
+
 // --- Handler ---
 
+// ctxHolder 使 atomic.Value 中存放的值类型一致
+type ctxHolder struct{ context.Context }
+
+// Context 返回 RunContext 使用的 ctx，未通过 RunContext 运行时返回 context.Background()
+func (b *Bot) Context() context.Context {
+	if h, ok := b.runCtx.Load().(ctxHolder); ok {
+		return h.Context
+	}
+	return context.Background()
+}
+
 // UseHandler 使用选定的 EventHandler 进行事件响应
-// 未实装
 func (b *Bot) UseHandler(handler EventHandler) {
 	b.handlers = handler
 }
 
-// Run 使用 eventHandler 进行事件相应
-// 与使用 FetchMessage 方法有所冲突
-// 未实装
+// Run 使用 EventHandler 进行事件响应
+// 与直接读取 Chan 有所冲突
+// 未通过 SetWorkers 设置并发时事件按顺序分发，处理函数中使用 WaitNext 时会阻塞后续事件的分发
 func (b *Bot) Run() {
+	b.RunContext(context.Background())
+}
+
+// RunContext 同 Run，ctx 取消时停止接收，等待处理中的事件完成后返回
+// 处理函数中通过 MessageContext.Ctx 或 Bot.Context 发起的请求将随 ctx 取消
+func (b *Bot) RunContext(ctx context.Context) {
+	b.runCtx.Store(ctxHolder{ctx})
 	go func() {
-		if err := b.FetchMessages(); err != nil {
+		if err := b.ListenContext(ctx); err != nil {
 			b.Client.Logger.Errorln(err)
 		}
 	}()
 
+	dispatch := func(e message.Event) { b.handlers.Dispatch(b, e) }
+	if b.workers > 1 {
+		pool := NewWorkerPool(b.workers, b.queueSize)
+		defer pool.Close()
+		dispatch = func(e message.Event) { pool.Submit(b, e, b.handlers.Dispatch) }
+	}
 	for {
-		e := <-b.Chan
-		switch e.Type {
-		case message.EventReceiveGroupMessage:
-
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-b.Chan:
+			dispatch(e)
 		}
 	}
 }
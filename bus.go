@@ -0,0 +1,140 @@
+package gomirai
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// SubscribeFilter 订阅过滤条件，为空的字段不作限制
+type SubscribeFilter struct {
+	// Types 事件类型，为 message 包中 Event* 常量
+	Types []string
+	// Groups 相关群号，见 EventGroup
+	Groups []int64
+	// Senders 相关用户QQ号，见 EventUser
+	Senders []int64
+}
+
+// match 判断事件是否满足过滤条件
+func (f SubscribeFilter) match(e message.Event) bool {
+	if len(f.Types) > 0 {
+		ok := false
+		for _, t := range f.Types {
+			if t == e.EventType() {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(f.Groups) > 0 && !containsID(f.Groups, EventGroup(e)) {
+		return false
+	}
+	if len(f.Senders) > 0 && !containsID(f.Senders, EventUser(e)) {
+		return false
+	}
+	return true
+}
+
+// Subscription 事件订阅，每个订阅拥有独立的缓冲区
+type Subscription struct {
+	// dropped 需保持64位对齐以使用 atomic，置于首位
+	dropped uint64
+	// C 满足过滤条件的事件，取消订阅后关闭
+	C      <-chan message.Event
+	ch     chan message.Event
+	filter SubscribeFilter
+	bot    *Bot
+}
+
+// eventBus Bot 的订阅列表
+type eventBus struct {
+	sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscribe 订阅事件，size 为缓冲区大小，缓冲区已满时丢弃新事件
+// 所有订阅均能收到完整的事件流，与 Chan 及 Run 互不影响
+func (b *Bot) Subscribe(filter SubscribeFilter, size int) *Subscription {
+	ch := make(chan message.Event, size)
+	s := &Subscription{C: ch, ch: ch, filter: filter, bot: b}
+	b.bus.Lock()
+	defer b.bus.Unlock()
+	if b.bus.subs == nil {
+		b.bus.subs = make(map[*Subscription]struct{})
+	}
+	b.bus.subs[s] = struct{}{}
+	return s
+}
+
+// Unsubscribe 取消订阅并关闭 C
+func (s *Subscription) Unsubscribe() {
+	s.bot.bus.Lock()
+	defer s.bot.bus.Unlock()
+	if _, ok := s.bot.bus.subs[s]; ok {
+		delete(s.bot.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+// Dropped 因缓冲区已满而丢弃的事件数量
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// publish 将事件发送给所有满足条件的订阅
+func (b *Bot) publish(e message.Event) {
+	b.bus.RLock()
+	defer b.bus.RUnlock()
+	for s := range b.bus.subs {
+		if !s.filter.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+			b.Logger.Debugln("Subscription Full, Event Dropped:", e.EventType())
+		}
+	}
+}
+
+// EventUser 事件相关的用户QQ号
+// 消息事件为发送者，成员事件为该成员，申请事件为申请人，其余事件返回 0
+func EventUser(e message.Event) int64 {
+	switch e := e.(type) {
+	case message.ChatEvent:
+		return e.Chat().Sender.ID
+	case *message.MemberJoinEvent:
+		return e.Member.ID
+	case *message.MemberLeaveEventKick:
+		return e.Member.ID
+	case *message.MemberLeaveEventQuit:
+		return e.Member.ID
+	case *message.MemberCardChangeEvent:
+		return e.Member.ID
+	case *message.MemberSpecialTitleChangeEvent:
+		return e.Member.ID
+	case *message.MemberPermissionChangeEvent:
+		return e.Member.ID
+	case *message.MemberMuteEvent:
+		return e.Member.ID
+	case *message.MemberUnmuteEvent:
+		return e.Member.ID
+	case *message.FriendRecallEvent:
+		return e.AuthorID
+	case *message.GroupRecallEvent:
+		return e.AuthorID
+	case *message.NewFriendRequestEvent:
+		return e.FromID
+	case *message.MemberJoinRequestEvent:
+		return e.FromID
+	case *message.BotInvitedJoinGroupRequestEvent:
+		return e.FromID
+	}
+	return 0
+}
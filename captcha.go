@@ -0,0 +1,371 @@
+package gomirai
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// Challenge 入群验证题目
+type Challenge struct {
+	// Question 发送给新成员的题目
+	Question string
+	// Answer 正确答案，比较时忽略首尾空白及大小写
+	Answer string
+}
+
+// randIntn 返回 [0, n) 中的随机数，使用 crypto/rand 以免题目被预测
+func randIntn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(err)
+	}
+	return int(v.Int64())
+}
+
+// ArithmeticChallenge 两位数加减法题目
+func ArithmeticChallenge() Challenge {
+	a, b := randIntn(90)+10, randIntn(90)+10
+	if randIntn(2) == 0 {
+		return Challenge{Question: fmt.Sprintf("%d + %d = ?", a, b), Answer: fmt.Sprint(a + b)}
+	}
+	if a < b {
+		a, b = b, a
+	}
+	return Challenge{Question: fmt.Sprintf("%d - %d = ?", a, b), Answer: fmt.Sprint(a - b)}
+}
+
+// CodeChallenge 复述 n 位验证码的题目
+func CodeChallenge(n int) func() Challenge {
+	const letters = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	return func() Challenge {
+		code := make([]byte, n)
+		for i := range code {
+			code[i] = letters[randIntn(len(letters))]
+		}
+		return Challenge{Question: "请发送验证码 " + string(code), Answer: string(code)}
+	}
+}
+
+// captchaPending 等待验证的成员，保存于状态文件中
+type captchaPending struct {
+	Group    int64     `json:"group"`
+	User     int64     `json:"user"`
+	Answer   string    `json:"answer"`
+	Deadline time.Time `json:"deadline"`
+	Attempts int       `json:"attempts"`
+	Muted    bool      `json:"muted"`
+	timer    *time.Timer
+}
+
+// Captcha 新成员入群验证
+// 新成员入群后发送题目，在 Timeout 内于群中或通过临时会话回答正确即通过，否则踢出
+// 启用 Mute 时新成员在验证期间被禁言，此时只能通过临时会话回答
+type Captcha struct {
+	// Timeout 回答时限，默认 5 分钟
+	Timeout time.Duration
+	// Challenge 题目生成函数，默认为 ArithmeticChallenge
+	Challenge func() Challenge
+	// Groups 启用验证的群，为空时所有群均启用
+	Groups []int64
+	// Mute 是否在验证期间禁言新成员
+	Mute bool
+	// MaxAttempts 最多回答次数，为 0 时不限制，超过后立即踢出
+	MaxAttempts int
+	// Greeting 题目消息，依次填入时限（秒）及题目
+	Greeting string
+	// PassMessage 验证通过后回复的消息，为空时不回复
+	PassMessage string
+	// WrongMessage 回答错误时回复的消息，为空时不回复
+	WrongMessage string
+	// KickMessage 踢出时附带的消息
+	KickMessage string
+	// OnPass 验证通过后调用
+	OnPass func(bot *Bot, group, qq int64)
+	// OnFail 验证失败并踢出后调用
+	OnFail func(bot *Bot, group, qq int64, err error)
+	// Auditor 不为 nil 时禁言、解除禁言及踢出通过其执行并写入审计记录，操作者为 Bot
+	Auditor *Auditor
+
+	bot       *Bot
+	statePath string
+	mu        sync.Mutex
+	pending   map[ConversationKey]*captchaPending
+	resumed   bool
+}
+
+// NewCaptcha 新建入群验证，statePath 为保存等待验证成员的文件，为空时不保存
+// 文件中已有的成员在 Resume 后重新计时
+func NewCaptcha(bot *Bot, statePath string) (*Captcha, error) {
+	c := &Captcha{
+		Timeout:      5 * time.Minute,
+		Challenge:    ArithmeticChallenge,
+		Greeting:     " 欢迎入群，请在 %d 秒内回答：%s",
+		PassMessage:  "验证通过",
+		WrongMessage: "回答错误",
+		KickMessage:  "入群验证未通过",
+		bot:          bot,
+		statePath:    statePath,
+		pending:      make(map[ConversationKey]*captchaPending),
+	}
+	if err := c.restore(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Attach 将入群验证注册至 EventHandler 并调用 Resume
+// 应在设置完成后调用
+func (c *Captcha) Attach(h *EventHandler) {
+	h.OnMemberJoin(func(bot *Bot, e *message.MemberJoinEvent) {
+		if len(c.Groups) > 0 && !containsID(c.Groups, e.Member.Group.ID) {
+			return
+		}
+		if err := c.Start(e.Member.Group.ID, e.Member.ID); err != nil {
+			bot.Logger.Errorln("Start Captcha Failed:", err)
+		}
+	})
+	h.OnMemberLeave(func(bot *Bot, e message.Event) {
+		c.remove(ConversationKey{Group: EventGroup(e), User: EventUser(e)})
+	})
+	h.OnMessage(func(mc *MessageContext) {
+		c.Check(mc)
+	})
+	c.Resume()
+}
+
+// Resume 为状态文件中的成员重新计时，已超时的成员立即踢出，仅首次调用有效
+// 不使用 Attach 时需在设置完成后手动调用
+func (c *Captcha) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resumed {
+		return
+	}
+	c.resumed = true
+	for _, p := range c.pending {
+		if p.timer == nil {
+			c.arm(p)
+		}
+	}
+}
+
+// Start 向成员发送题目并开始计时
+func (c *Captcha) Start(group, qq int64) error {
+	ch := c.Challenge()
+	p := &captchaPending{
+		Group:    group,
+		User:     qq,
+		Answer:   ch.Answer,
+		Deadline: time.Now().Add(c.Timeout),
+	}
+	chain := message.NewBuilder().At(qq).Textf(c.Greeting, int64(c.Timeout/time.Second), ch.Question).Build()
+	if _, err := c.bot.SendGroupMessageContext(c.bot.Context(), group, 0, chain.Msg...); err != nil {
+		return err
+	}
+	if c.Mute {
+		if err := c.mute(group, qq); err != nil {
+			c.bot.Logger.Warnln("Captcha Mute Failed:", err)
+		} else {
+			p.Muted = true
+		}
+	}
+	c.mu.Lock()
+	c.add(p)
+	c.mu.Unlock()
+	c.save()
+	return nil
+}
+
+// Pending 判断成员是否正在等待验证
+func (c *Captcha) Pending(group, qq int64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.pending[ConversationKey{Group: group, User: qq}]
+	return ok
+}
+
+// Check 检查消息是否为等待验证成员的回答，返回消息是否已被处理
+func (c *Captcha) Check(mc *MessageContext) bool {
+	key := KeyOf(mc.Event)
+	c.mu.Lock()
+	p, ok := c.pending[key]
+	if !ok {
+		c.mu.Unlock()
+		return false
+	}
+	if strings.EqualFold(strings.TrimSpace(mc.Chain().PlainText()), p.Answer) {
+		c.drop(key, p)
+		c.mu.Unlock()
+		c.save()
+		c.pass(mc, p)
+		return true
+	}
+	p.Attempts++
+	exceeded := c.MaxAttempts > 0 && p.Attempts >= c.MaxAttempts
+	if exceeded {
+		c.drop(key, p)
+	}
+	c.mu.Unlock()
+	c.save()
+	if exceeded {
+		c.kick(p)
+	} else if c.WrongMessage != "" {
+		mc.ReplyQuoted(message.NewBuilder().Text(c.WrongMessage).Build())
+	}
+	return true
+}
+
+// pass 验证通过
+func (c *Captcha) pass(mc *MessageContext, p *captchaPending) {
+	if p.Muted {
+		if err := c.unmute(p.Group, p.User); err != nil {
+			c.bot.Logger.Warnln("Captcha UnMute Failed:", err)
+		}
+	}
+	if c.PassMessage != "" {
+		mc.ReplyQuoted(message.NewBuilder().Text(c.PassMessage).Build())
+	}
+	c.bot.Logger.Infoln("Captcha Passed:", p.User, "in", p.Group)
+	if c.OnPass != nil {
+		c.OnPass(c.bot, p.Group, p.User)
+	}
+}
+
+// kick 验证失败，踢出成员
+func (c *Captcha) kick(p *captchaPending) {
+	var err error
+	if c.Auditor != nil {
+		err = c.Auditor.KickContext(c.bot.Context(), c.bot.QQ, p.Group, p.User, c.KickMessage, captchaReason)
+	} else {
+		err = c.bot.KickContext(c.bot.Context(), p.Group, p.User, c.KickMessage)
+	}
+	if err != nil {
+		c.bot.Logger.Errorln("Captcha Kick Failed:", err)
+	} else {
+		c.bot.Logger.Infoln("Captcha Failed, Kicked:", p.User, "in", p.Group)
+	}
+	if c.OnFail != nil {
+		c.OnFail(c.bot, p.Group, p.User, err)
+	}
+}
+
+// captchaReason 审计记录中的原因
+const captchaReason = "入群验证"
+
+// mute 验证期间禁言
+func (c *Captcha) mute(group, qq int64) error {
+	if c.Auditor != nil {
+		return c.Auditor.MuteContext(c.bot.Context(), c.bot.QQ, group, qq, c.Timeout, captchaReason)
+	}
+	return c.bot.MuteContext(c.bot.Context(), group, qq, int64(c.Timeout/time.Second))
+}
+
+// unmute 验证通过后解除禁言
+func (c *Captcha) unmute(group, qq int64) error {
+	if c.Auditor != nil {
+		return c.Auditor.UnMuteContext(c.bot.Context(), c.bot.QQ, group, qq, captchaReason)
+	}
+	return c.bot.UnMuteContext(c.bot.Context(), group, qq)
+}
+
+// expire 超时处理
+func (c *Captcha) expire(p *captchaPending) {
+	key := ConversationKey{Group: p.Group, User: p.User}
+	c.mu.Lock()
+	if c.pending[key] != p {
+		c.mu.Unlock()
+		return
+	}
+	c.drop(key, p)
+	c.mu.Unlock()
+	c.save()
+	c.kick(p)
+}
+
+// add 添加等待验证的成员并开始计时，调用时需持有锁
+func (c *Captcha) add(p *captchaPending) {
+	key := ConversationKey{Group: p.Group, User: p.User}
+	if old, ok := c.pending[key]; ok && old.timer != nil {
+		old.timer.Stop()
+	}
+	c.pending[key] = p
+	c.arm(p)
+}
+
+// arm 开始计时，调用时需持有锁
+func (c *Captcha) arm(p *captchaPending) {
+	p.timer = time.AfterFunc(time.Until(p.Deadline), func() { c.expire(p) })
+}
+
+// drop 移除等待验证的成员，调用时需持有锁
+func (c *Captcha) drop(key ConversationKey, p *captchaPending) {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	delete(c.pending, key)
+}
+
+// remove 成员离群时移除
+func (c *Captcha) remove(key ConversationKey) {
+	c.mu.Lock()
+	p, ok := c.pending[key]
+	if ok {
+		c.drop(key, p)
+	}
+	c.mu.Unlock()
+	if ok {
+		c.save()
+	}
+}
+
+// save 保存等待验证的成员
+func (c *Captcha) save() {
+	if c.statePath == "" {
+		return
+	}
+	// 在锁内复制，避免序列化时 Attempts 被修改
+	c.mu.Lock()
+	list := make([]captchaPending, 0, len(c.pending))
+	for _, p := range c.pending {
+		list = append(list, *p)
+	}
+	c.mu.Unlock()
+	if err := writeJSONAtomic(c.statePath, list); err != nil {
+		c.bot.Logger.Errorln("Save Captcha State Failed:", err)
+	}
+}
+
+// restore 读取状态文件，在 Resume 时开始计时
+func (c *Captcha) restore() error {
+	if c.statePath == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(c.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var list []*captchaPending
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range list {
+		c.pending[ConversationKey{Group: p.Group, User: p.User}] = p
+	}
+	if len(list) > 0 {
+		c.bot.Logger.Infoln("Captcha Restored:", len(list), "Pending")
+	}
+	return nil
+}
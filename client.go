@@ -1,13 +1,13 @@
 package gomirai
 
 import (
-	"errors"
-	"fmt"
+	"context"
 	"io"
-	"strconv"
+	"sync"
 	"time"
 
 	"gopkg.in/h2non/gentleman.v2"
+	gcontext "gopkg.in/h2non/gentleman.v2/context"
 	"gopkg.in/h2non/gentleman.v2/plugins/body"
 	"gopkg.in/h2non/gentleman.v2/plugins/multipart"
 
@@ -17,11 +17,19 @@ import (
 
 // Client 与Mirai进行沟通
 type Client struct {
-	Name       string
+	Name string
+	// AuthKey 1.x 中的 authKey，2.x 中的 verifyKey
 	AuthKey    string
 	HTTPClient *gentleman.Client
 	Bots       map[int64]*Bot
 	Logger     *logrus.Entry
+	// OnSessionRecover Bot 的 Session 失效并尝试恢复后调用，恢复成功时 err 为 nil
+	OnSessionRecover func(bot *Bot, failedKey string, err error)
+	// Protocol 协议版本，为 ProtocolAuto ProtocolV1 ProtocolV2 之一，默认自动检测
+	// 需在首次认证前设置
+	Protocol int
+	protoMu  sync.Mutex
+	url      string
 }
 
 // NewClient 新建Client
@@ -42,6 +50,7 @@ func NewClient(name, url, authKey string, logger ...*logrus.Entry) *Client {
 		HTTPClient: c,
 		Bots:       make(map[int64]*Bot),
 		Logger:     log,
+		url:        url,
 	}
 }
 
@@ -49,7 +58,12 @@ func NewClient(name, url, authKey string, logger ...*logrus.Entry) *Client {
 
 // About 使用此方法获取插件的信息，如版本号
 func (c *Client) About() (string, error) {
-	res, err := c.doGet("/about", nil)
+	return c.AboutContext(context.Background())
+}
+
+// AboutContext 同 About，可通过 ctx 取消请求
+func (c *Client) AboutContext(ctx context.Context) (string, error) {
+	res, err := c.doGet(ctx, "/about", nil)
 	if err != nil {
 		return "", err
 	}
@@ -59,9 +73,18 @@ func (c *Client) About() (string, error) {
 // --- 认证相关 ---
 
 // Auth 使用此方法验证你的身份，并返回一个会话
+// 2.x 中对应 /verify 接口
 func (c *Client) Auth() (string, error) {
-	data := map[string]string{"authKey": c.AuthKey}
-	res, err := c.doPost("/auth", data)
+	return c.AuthContext(context.Background())
+}
+
+// AuthContext 同 Auth，可通过 ctx 取消请求
+func (c *Client) AuthContext(ctx context.Context) (string, error) {
+	path, data := "/auth", map[string]string{"authKey": c.AuthKey}
+	if c.protocol(ctx) == ProtocolV2 {
+		path, data = "/verify", map[string]string{"verifyKey": c.AuthKey}
+	}
+	res, err := c.doPost(ctx, path, data)
 	if err != nil {
 		return "", err
 	}
@@ -70,10 +93,14 @@ func (c *Client) Auth() (string, error) {
 }
 
 // Verify 使用此方法校验并激活你的Session，同时将Session与一个已登录的Bot绑定
+// 2.x 中对应 /bind 接口
 func (c *Client) Verify(qq int64, sessionKey string) (*Bot, error) {
-	data := map[string]interface{}{"sessionKey": sessionKey, "qq": qq}
-	_, err := c.doPost("/verify", data)
-	if err != nil {
+	return c.VerifyContext(context.Background(), qq, sessionKey)
+}
+
+// VerifyContext 同 Verify，可通过 ctx 取消请求
+func (c *Client) VerifyContext(ctx context.Context, qq int64, sessionKey string) (*Bot, error) {
+	if err := c.verify(ctx, qq, sessionKey); err != nil {
 		return nil, err
 	}
 	c.Bots[qq] = &Bot{QQ: qq, SessionKey: sessionKey, Client: c, Logger: c.Logger.WithField("qq", qq)}
@@ -85,8 +112,13 @@ func (c *Client) Verify(qq int64, sessionKey string) (*Bot, error) {
 // Release 使用此方式释放session及其相关资源（Bot不会被释放）
 // 不使用的Session应当被释放，长时间（30分钟）未使用的Session将自动释放，否则Session持续保存Bot收到的消息，将会导致内存泄露(开启websocket后将不会自动释放)
 func (c *Client) Release(qq int64) error {
-	data := map[string]interface{}{"sessionKey": c.Bots[qq].SessionKey, "qq": qq}
-	_, err := c.doPost("release", data)
+	return c.ReleaseContext(context.Background(), qq)
+}
+
+// ReleaseContext 同 Release，可通过 ctx 取消请求
+func (c *Client) ReleaseContext(ctx context.Context, qq int64) error {
+	data := map[string]interface{}{"sessionKey": c.Bots[qq].session(), "qq": qq}
+	_, err := c.doPost(ctx, "release", data)
 	if err != nil {
 		return err
 	}
@@ -97,9 +129,29 @@ func (c *Client) Release(qq int64) error {
 
 // --- internal ---
 
-func (c *Client) doPost(path string, data interface{}) (string, error) {
+func (c *Client) verify(ctx context.Context, qq int64, sessionKey string) error {
+	path := "/verify"
+	if c.protocol(ctx) == ProtocolV2 {
+		path = "/bind"
+	}
+	data := map[string]interface{}{"sessionKey": sessionKey, "qq": qq}
+	_, err := c.doPost(ctx, path, data)
+	return err
+}
+
+// withContext 使请求随 ctx 取消，同时保留 gentleman 的上下文存储
+func withContext(ctx context.Context) gcontext.HandlerFunc {
+	return func(c *gcontext.Context, h gcontext.Handler) {
+		store := c.Request.Context().Value(gcontext.Key)
+		c.Request = c.Request.WithContext(context.WithValue(ctx, gcontext.Key, store))
+		h.Next(c)
+	}
+}
+
+func (c *Client) doPost(ctx context.Context, path string, data interface{}) (string, error) {
 	c.Logger.Debugln("POST:", path, " Data:", data)
 	res, err := c.HTTPClient.Request().
+		UseRequest(withContext(ctx)).
 		Path(path).
 		Method("POST").
 		Use(body.JSON(data)).
@@ -110,13 +162,10 @@ func (c *Client) doPost(path string, data interface{}) (string, error) {
 		return "", err
 	}
 	c.Logger.Trace("result StatusCode:", res.StatusCode)
-	if !res.Ok {
-		return res.String(), errors.New("Http: " + strconv.Itoa(res.StatusCode))
-	}
-	return res.String(), getErrByCode(gjson.Get(res.String(), "code").Int())
+	return res.String(), newAPIError(path, res.StatusCode, gjson.Get(res.String(), "code").Int())
 }
 
-func (c *Client) doPostWithFormData(path string, fields map[string]interface{}) (string, error) {
+func (c *Client) doPostWithFormData(ctx context.Context, path string, fields map[string]interface{}) (string, error) {
 	data := make(multipart.DataFields)
 	files := make([]multipart.FormFile, 0)
 	for key, value := range fields {
@@ -129,6 +178,7 @@ func (c *Client) doPostWithFormData(path string, fields map[string]interface{})
 	formData := multipart.FormData{Data: data, Files: files}
 	c.Logger.Trace("POST:"+path+" FormData:", formData)
 	res, err := c.HTTPClient.Request().
+		UseRequest(withContext(ctx)).
 		Path(path).
 		Method("POST").
 		Use(multipart.Data(formData)).
@@ -138,15 +188,13 @@ func (c *Client) doPostWithFormData(path string, fields map[string]interface{})
 		return "", err
 	}
 	c.Logger.Debugln("result StatusCode:", res.StatusCode)
-	if !res.Ok {
-		return res.String(), fmt.Errorf("HTTP: %d", res.StatusCode)
-	}
-	return res.String(), getErrByCode(gjson.Get(res.String(), "code").Int())
+	return res.String(), newAPIError(path, res.StatusCode, gjson.Get(res.String(), "code").Int())
 }
 
-func (c *Client) doGet(path string, params map[string]string) (string, error) {
+func (c *Client) doGet(ctx context.Context, path string, params map[string]string) (string, error) {
 	c.Logger.Debugln("GET:", path)
 	res, err := c.HTTPClient.Request().
+		UseRequest(withContext(ctx)).
 		Path(path).
 		SetQueryParams(params).
 		Method("GET").
@@ -157,37 +205,5 @@ func (c *Client) doGet(path string, params map[string]string) (string, error) {
 		return "", err
 	}
 	c.Logger.Debugln("result StatusCode:", res.StatusCode)
-	if !res.Ok {
-		return res.String(), fmt.Errorf("HTTP: %d", res.StatusCode)
-	}
-	return res.String(), getErrByCode(gjson.Get(res.String(), "code").Int())
-}
-
-func getErrByCode(code int64) error {
-	switch code {
-	case 0:
-		return nil
-	case 1:
-		return fmt.Errorf("错误的auth key")
-	case 2:
-		return fmt.Errorf("指定的Bot不存在")
-	case 3:
-		return fmt.Errorf("Session失效或不存在")
-	case 4:
-		return fmt.Errorf("Session未认证(未激活)")
-	case 5:
-		return fmt.Errorf("发送消息目标不存在(指定对象不存在)")
-	case 6:
-		return fmt.Errorf("指定文件不存在，出现于发送本地图片")
-	case 10:
-		return fmt.Errorf("无操作权限，指Bot没有对应操作的限权")
-	case 20:
-		return fmt.Errorf("Bot被禁言，指Bot当前无法向指定群发送消息")
-	case 30:
-		return fmt.Errorf("消息过长")
-	case 400:
-		return fmt.Errorf("错误的访问，如参数错误等")
-	default:
-		return fmt.Errorf("未知错误，Code: %d", code)
-	}
+	return res.String(), newAPIError(path, res.StatusCode, gjson.Get(res.String(), "code").Int())
 }
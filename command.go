@@ -0,0 +1,351 @@
+package gomirai
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// Command 命令
+type Command struct {
+	// Name 命令名
+	Name string
+	// Aliases 别名
+	Aliases []string
+	// Usage 参数用法，如 "<qq> [时长]"
+	Usage string
+	// Description 命令说明
+	Description string
+	// Subcommands 子命令
+	Subcommands []*Command
+	// Guard 权限守卫，同时作用于子命令
+	Guard *Guard
+	// Handler 命令处理函数，返回的错误将连同用法回复给用户
+	// 为 nil 时回复该命令的帮助
+	Handler func(c *CommandContext) error
+}
+
+// match 判断名称是否为该命令或其别名
+func (cmd *Command) match(name string) bool {
+	if strings.EqualFold(cmd.Name, name) {
+		return true
+	}
+	for _, alias := range cmd.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// find 查找子命令
+func (cmd *Command) find(name string) *Command {
+	for _, sub := range cmd.Subcommands {
+		if sub.match(name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Arg 命令参数
+type Arg struct {
+	// Text 参数文本，At消息为 "@QQ号"
+	Text string
+	// AtQQ At消息中被@的QQ号，其他参数为 0
+	AtQQ int64
+}
+
+// String 参数文本
+func (a Arg) String() string {
+	return a.Text
+}
+
+// Int 将参数解析为整数
+func (a Arg) Int() (int64, error) {
+	return strconv.ParseInt(a.Text, 10, 64)
+}
+
+// QQ 将参数解析为QQ号，支持At消息及数字
+func (a Arg) QQ() (int64, error) {
+	if a.AtQQ != 0 {
+		return a.AtQQ, nil
+	}
+	qq, err := strconv.ParseInt(strings.TrimPrefix(a.Text, "@"), 10, 64)
+	if err != nil || qq <= 0 {
+		return 0, fmt.Errorf("无效的QQ号: %s", a.Text)
+	}
+	return qq, nil
+}
+
+// Duration 将参数解析为时长，支持 time.ParseDuration 格式及秒数
+func (a Arg) Duration() (time.Duration, error) {
+	if s, err := strconv.ParseInt(a.Text, 10, 64); err == nil {
+		return time.Duration(s) * time.Second, nil
+	}
+	d, err := time.ParseDuration(a.Text)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时长: %s", a.Text)
+	}
+	return d, nil
+}
+
+// CommandContext 命令上下文
+type CommandContext struct {
+	*MessageContext
+	// Command 匹配到的命令
+	Command *Command
+	// Path 从根命令开始的命令名
+	Path []string
+	// Args 命令参数
+	Args []Arg
+	// Router 所属路由
+	Router *CommandRouter
+}
+
+// Arg 第 i 个参数，不存在时返回零值
+func (c *CommandContext) Arg(i int) Arg {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return Arg{}
+}
+
+// ErrUsage 参数错误，回复用法
+var ErrUsage = errors.New("参数错误")
+
+// CommandRouter 命令路由
+// 通过 Attach 注册至 EventHandler 使用
+type CommandRouter struct {
+	// Prefixes 命令前缀
+	Prefixes []string
+	// AtBot 是否允许通过 "@Bot 命令" 触发（此时前缀可省略）
+	AtBot bool
+	// DisableHelp 是否禁用自动生成的 help 命令
+	DisableHelp bool
+	// Guard 作用于所有命令的权限守卫
+	Guard    *Guard
+	commands []*Command
+}
+
+// NewCommandRouter 新建命令路由，未指定前缀时使用 "/"
+func NewCommandRouter(prefixes ...string) *CommandRouter {
+	if len(prefixes) == 0 {
+		prefixes = []string{"/"}
+	}
+	return &CommandRouter{Prefixes: prefixes, AtBot: true}
+}
+
+// Register 注册命令
+func (r *CommandRouter) Register(cmds ...*Command) {
+	r.commands = append(r.commands, cmds...)
+}
+
+// Attach 将命令路由注册至 EventHandler
+func (r *CommandRouter) Attach(h *EventHandler) {
+	h.OnMessage(func(c *MessageContext) {
+		r.Handle(c)
+	})
+}
+
+// Commands 已注册的命令
+func (r *CommandRouter) Commands() []*Command {
+	return r.commands
+}
+
+// find 查找根命令
+func (r *CommandRouter) find(name string) *Command {
+	for _, cmd := range r.commands {
+		if cmd.match(name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Handle 解析消息并执行对应命令，返回是否匹配到命令
+func (r *CommandRouter) Handle(c *MessageContext) bool {
+	args, ok := r.parse(c)
+	if !ok || len(args) == 0 {
+		return false
+	}
+	name := args[0].Text
+	cmd := r.find(name)
+	if cmd == nil {
+		if r.DisableHelp || !strings.EqualFold(name, "help") {
+			return false
+		}
+		r.replyHelp(c, args[1:])
+		return true
+	}
+	guards := []*Guard{r.Guard, cmd.Guard}
+	path := []string{cmd.Name}
+	args = args[1:]
+	for len(args) > 0 {
+		sub := cmd.find(args[0].Text)
+		if sub == nil {
+			break
+		}
+		cmd, path, args = sub, append(path, sub.Name), args[1:]
+		guards = append(guards, cmd.Guard)
+	}
+	for _, g := range guards {
+		if g != nil && !g.Allow(c.Event) {
+			c.ReplyText(g.deny())
+			return true
+		}
+	}
+	cc := &CommandContext{MessageContext: c, Command: cmd, Path: path, Args: args, Router: r}
+	if cmd.Handler == nil {
+		c.ReplyText(r.help(cmd, path))
+		return true
+	}
+	if err := cmd.Handler(cc); err != nil {
+		c.Bot.Logger.Debugln("Command", strings.Join(path, " "), "Error:", err)
+		c.ReplyText(err.Error() + "\n用法: " + r.usage(cmd, path))
+	}
+	return true
+}
+
+// parse 判断是否触发命令并拆分参数，返回的第一个参数为去除前缀的命令名
+func (r *CommandRouter) parse(c *MessageContext) ([]Arg, bool) {
+	var args []Arg
+	atBot := false
+	for _, m := range c.Event.MessageChain {
+		switch m.Type {
+		case message.MsgTypePlain:
+			for _, s := range splitArgs(m.Text) {
+				args = append(args, Arg{Text: s})
+			}
+		case message.MsgTypeAt:
+			if len(args) == 0 && r.AtBot && m.Target == c.Bot.QQ {
+				atBot = true
+				continue
+			}
+			args = append(args, Arg{Text: "@" + strconv.FormatInt(m.Target, 10), AtQQ: m.Target})
+		}
+	}
+	if len(args) == 0 || args[0].AtQQ != 0 {
+		return nil, false
+	}
+	for _, prefix := range r.Prefixes {
+		if strings.HasPrefix(args[0].Text, prefix) && len(args[0].Text) > len(prefix) {
+			args[0].Text = args[0].Text[len(prefix):]
+			return args, true
+		}
+	}
+	return args, atBot
+}
+
+// usage 命令用法
+func (r *CommandRouter) usage(cmd *Command, path []string) string {
+	prefix := ""
+	if len(r.Prefixes) > 0 {
+		prefix = r.Prefixes[0]
+	}
+	u := prefix + strings.Join(path, " ")
+	if len(cmd.Subcommands) > 0 && cmd.Handler == nil {
+		u += " <子命令>"
+	}
+	if cmd.Usage != "" {
+		u += " " + cmd.Usage
+	}
+	return u
+}
+
+// help 单个命令的帮助
+func (r *CommandRouter) help(cmd *Command, path []string) string {
+	var sb strings.Builder
+	sb.WriteString("用法: " + r.usage(cmd, path))
+	if cmd.Description != "" {
+		sb.WriteString("\n" + cmd.Description)
+	}
+	if len(cmd.Aliases) > 0 {
+		sb.WriteString("\n别名: " + strings.Join(cmd.Aliases, ", "))
+	}
+	if len(cmd.Subcommands) > 0 {
+		sb.WriteString("\n子命令:")
+		for _, sub := range cmd.Subcommands {
+			sb.WriteString("\n  " + sub.Name)
+			if sub.Description != "" {
+				sb.WriteString(" - " + sub.Description)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// replyHelp 回复帮助，args 为空时列出所有命令
+func (r *CommandRouter) replyHelp(c *MessageContext, args []Arg) {
+	if len(args) > 0 {
+		cmd := r.find(args[0].Text)
+		if cmd == nil {
+			c.ReplyText("未知命令: " + args[0].Text)
+			return
+		}
+		path := []string{cmd.Name}
+		for _, a := range args[1:] {
+			sub := cmd.find(a.Text)
+			if sub == nil {
+				break
+			}
+			cmd, path = sub, append(path, sub.Name)
+		}
+		c.ReplyText(r.help(cmd, path))
+		return
+	}
+	lines := make([]string, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		line := r.usage(cmd, []string{cmd.Name})
+		if cmd.Description != "" {
+			line += " - " + cmd.Description
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	c.ReplyText("可用命令:\n" + strings.Join(lines, "\n"))
+}
+
+// splitArgs 按 shell 规则拆分参数，支持单双引号及反斜杠转义
+func splitArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+	escaped := false
+	for _, ch := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(ch)
+			escaped = false
+		case ch == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(ch)
+			}
+		case ch == '"' || ch == '\'':
+			quote, inArg = ch, true
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(ch)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
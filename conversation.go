@@ -0,0 +1,178 @@
+package gomirai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/virzz/gomirai/message"
+)
+
+var (
+	// ErrWaitTimeout 等待超时
+	ErrWaitTimeout = errors.New("等待超时")
+	// ErrConversationBusy 该用户已有进行中的会话
+	ErrConversationBusy = errors.New("会话进行中")
+	// ErrConversationEnded 会话已结束
+	ErrConversationEnded = errors.New("会话已结束")
+)
+
+// waiter 等待中的事件过滤器
+type waiter struct {
+	filter func(message.Event) bool
+	ch     chan message.Event
+}
+
+// waiters Bot 的等待队列
+type waiters struct {
+	sync.Mutex
+	list          []*waiter
+	conversations map[ConversationKey]*Conversation
+}
+
+// WaitNext 等待下一个满足 filter 的事件
+// 匹配的事件在进入 Chan 之前被拦截，不会交由其他处理函数处理
+// timeout 为 0 时仅受 ctx 控制，超时返回 ErrWaitTimeout
+func (b *Bot) WaitNext(ctx context.Context, filter func(message.Event) bool, timeout time.Duration) (message.Event, error) {
+	w := &waiter{filter: filter, ch: make(chan message.Event, 1)}
+	b.waiters.Lock()
+	b.waiters.list = append(b.waiters.list, w)
+	b.waiters.Unlock()
+
+	var expire <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expire = t.C
+	}
+	var err error
+	select {
+	case e := <-w.ch:
+		return e, nil
+	case <-expire:
+		err = ErrWaitTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	// 超时的同时事件已被拦截时返回该事件，以免丢失
+	if !b.removeWaiter(w) {
+		return <-w.ch, nil
+	}
+	return nil, err
+}
+
+// removeWaiter 移除等待中的过滤器，返回 false 表示已被 intercept 取出
+func (b *Bot) removeWaiter(w *waiter) bool {
+	b.waiters.Lock()
+	defer b.waiters.Unlock()
+	for i, v := range b.waiters.list {
+		if v == w {
+			b.waiters.list = append(b.waiters.list[:i], b.waiters.list[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// intercept 将事件交给第一个匹配的等待者，返回事件是否已被拦截
+func (b *Bot) intercept(e message.Event) bool {
+	b.waiters.Lock()
+	defer b.waiters.Unlock()
+	for i, w := range b.waiters.list {
+		if w.filter(e) {
+			b.waiters.list = append(b.waiters.list[:i], b.waiters.list[i+1:]...)
+			w.ch <- e
+			return true
+		}
+	}
+	return false
+}
+
+// ConversationKey 会话标识，好友消息的 Group 为 0
+type ConversationKey struct {
+	Group int64
+	User  int64
+}
+
+// KeyOf 消息事件对应的会话标识
+func KeyOf(e message.ChatEvent) ConversationKey {
+	s := e.Chat().Sender
+	return ConversationKey{Group: s.Group.ID, User: s.ID}
+}
+
+// Conversation 与同一群中同一用户（或同一好友）的多轮会话
+type Conversation struct {
+	*MessageContext
+	// Key 会话标识
+	Key ConversationKey
+	// Timeout 每轮等待的超时时间，超时后会话自动结束
+	Timeout time.Duration
+	ended   bool
+}
+
+// StartConversation 以收到的消息开启会话
+// 同一会话标识同时只能有一个进行中的会话，否则返回 ErrConversationBusy
+func (b *Bot) StartConversation(c *MessageContext, timeout time.Duration) (*Conversation, error) {
+	key := KeyOf(c.Event)
+	b.waiters.Lock()
+	defer b.waiters.Unlock()
+	if _, ok := b.waiters.conversations[key]; ok {
+		return nil, ErrConversationBusy
+	}
+	if b.waiters.conversations == nil {
+		b.waiters.conversations = make(map[ConversationKey]*Conversation)
+	}
+	conv := &Conversation{MessageContext: c, Key: key, Timeout: timeout}
+	b.waiters.conversations[key] = conv
+	return conv, nil
+}
+
+// InConversation 判断会话标识是否有进行中的会话
+func (b *Bot) InConversation(key ConversationKey) bool {
+	b.waiters.Lock()
+	defer b.waiters.Unlock()
+	_, ok := b.waiters.conversations[key]
+	return ok
+}
+
+// Next 等待该用户的下一条消息，并将会话的 MessageContext 更新为该消息
+// 超时后会话结束并返回 ErrWaitTimeout
+func (c *Conversation) Next(ctx context.Context) (*MessageContext, error) {
+	if c.ended {
+		return nil, ErrConversationEnded
+	}
+	e, err := c.Bot.WaitNext(ctx, func(e message.Event) bool {
+		chat, ok := e.(message.ChatEvent)
+		return ok && KeyOf(chat) == c.Key
+	}, c.Timeout)
+	if err != nil {
+		c.End()
+		return nil, err
+	}
+	next := NewMessageContext(c.Bot, e.(message.ChatEvent))
+	next.Ctx = c.MessageContext.Ctx
+	c.MessageContext = next
+	return next, nil
+}
+
+// Ask 回复 prompt 并等待该用户的下一条消息
+func (c *Conversation) Ask(ctx context.Context, prompt string) (*MessageContext, error) {
+	if _, err := c.ReplyText(prompt); err != nil {
+		return nil, err
+	}
+	return c.Next(ctx)
+}
+
+// End 结束会话
+func (c *Conversation) End() {
+	if c.ended {
+		return
+	}
+	c.ended = true
+	c.Bot.waiters.Lock()
+	defer c.Bot.waiters.Unlock()
+	if c.Bot.waiters.conversations[c.Key] == c {
+		delete(c.Bot.waiters.conversations, c.Key)
+	}
+}
@@ -0,0 +1,86 @@
+package gomirai
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// APIError Mirai-api-http 返回的错误
+// 可通过 errors.Is 与 Err* 比较状态码，或通过 errors.As 获取详细信息
+type APIError struct {
+	// Code 返回的状态码，HTTP 请求失败时为 0
+	Code int64
+	// Path 请求的接口
+	Path string
+	// StatusCode HTTP 状态码
+	StatusCode int
+	// Msg 错误说明
+	Msg string
+}
+
+// 状态码对应的错误
+var (
+	// ErrWrongAuthKey 错误的auth key
+	ErrWrongAuthKey = &APIError{Code: 1, Msg: "错误的auth key"}
+	// ErrBotNotExist 指定的Bot不存在
+	ErrBotNotExist = &APIError{Code: 2, Msg: "指定的Bot不存在"}
+	// ErrSessionInvalid Session失效或不存在
+	ErrSessionInvalid = &APIError{Code: 3, Msg: "Session失效或不存在"}
+	// ErrSessionNotVerified Session未认证(未激活)
+	ErrSessionNotVerified = &APIError{Code: 4, Msg: "Session未认证(未激活)"}
+	// ErrTargetNotExist 发送消息目标不存在(指定对象不存在)
+	ErrTargetNotExist = &APIError{Code: 5, Msg: "发送消息目标不存在(指定对象不存在)"}
+	// ErrFileNotExist 指定文件不存在，出现于发送本地图片
+	ErrFileNotExist = &APIError{Code: 6, Msg: "指定文件不存在，出现于发送本地图片"}
+	// ErrNoPermission 无操作权限，指Bot没有对应操作的限权
+	ErrNoPermission = &APIError{Code: 10, Msg: "无操作权限，指Bot没有对应操作的限权"}
+	// ErrBotMuted Bot被禁言，指Bot当前无法向指定群发送消息
+	ErrBotMuted = &APIError{Code: 20, Msg: "Bot被禁言，指Bot当前无法向指定群发送消息"}
+	// ErrMessageTooLong 消息过长
+	ErrMessageTooLong = &APIError{Code: 30, Msg: "消息过长"}
+	// ErrBadRequest 错误的访问，如参数错误等
+	ErrBadRequest = &APIError{Code: 400, Msg: "错误的访问，如参数错误等"}
+)
+
+var codeErrors = map[int64]*APIError{}
+
+func init() {
+	for _, e := range []*APIError{
+		ErrWrongAuthKey, ErrBotNotExist, ErrSessionInvalid, ErrSessionNotVerified, ErrTargetNotExist,
+		ErrFileNotExist, ErrNoPermission, ErrBotMuted, ErrMessageTooLong, ErrBadRequest,
+	} {
+		codeErrors[e.Code] = e
+	}
+}
+
+func (e *APIError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s (code: %d, http: %d)", e.Path, e.Msg, e.Code, e.StatusCode)
+}
+
+// Is 状态码相同即视为同一错误
+// target 的 StatusCode 不为 0 时还需 HTTP 状态码相同
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && (t.StatusCode == 0 || t.StatusCode == e.StatusCode)
+}
+
+// newAPIError 根据状态码生成错误，code 为 0 且 HTTP 请求成功时返回 nil
+func newAPIError(path string, statusCode int, code int64) error {
+	if statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices {
+		return &APIError{Code: code, Path: path, StatusCode: statusCode, Msg: fmt.Sprintf("HTTP: %d", statusCode)}
+	}
+	if code == 0 {
+		return nil
+	}
+	e := &APIError{Code: code, Path: path, StatusCode: statusCode, Msg: fmt.Sprintf("未知错误，Code: %d", code)}
+	if known, ok := codeErrors[code]; ok {
+		e.Msg = known.Msg
+	}
+	return e
+}
@@ -7,6 +7,6 @@ require (
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.3.0 // indirect
 	github.com/tidwall/gjson v1.6.0
-	golang.org/x/net v0.0.0-20200625001655-4c5254603344 // indirect
+	golang.org/x/net v0.0.0-20200625001655-4c5254603344
 	gopkg.in/h2non/gentleman.v2 v2.0.4
 )
@@ -0,0 +1,77 @@
+package gomirai
+
+import (
+	"github.com/virzz/gomirai/message"
+)
+
+// Guard 权限守卫
+// 仅对消息事件生效，其他事件总是放行
+type Guard struct {
+	// MinRole 最低群角色，为 message.OWNER message.ADMINISTRATOR message.MEMBER 之一，为 0 时不限制
+	// 设置后非群消息仅超级用户可通过
+	MinRole int
+	// Superusers 超级用户，不受其他限制
+	Superusers []int64
+	// AllowGroups 允许的群，为空时不限制
+	AllowGroups []int64
+	// DenyGroups 禁止的群
+	DenyGroups []int64
+	// Message 拒绝时回复的消息，为空时使用 "权限不足"
+	Message string
+}
+
+// containsID 判断 ids 是否包含 id
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSuperuser 判断是否为超级用户
+func (g *Guard) IsSuperuser(qq int64) bool {
+	return containsID(g.Superusers, qq)
+}
+
+// Allow 判断事件是否可以通过
+func (g *Guard) Allow(e message.Event) bool {
+	c, ok := e.(message.ChatEvent)
+	if !ok {
+		return true
+	}
+	s := c.Chat().Sender
+	if g.IsSuperuser(s.ID) {
+		return true
+	}
+	if group := s.Group.ID; group != 0 {
+		if containsID(g.DenyGroups, group) {
+			return false
+		}
+		if len(g.AllowGroups) > 0 && !containsID(g.AllowGroups, group) {
+			return false
+		}
+	}
+	if g.MinRole == 0 {
+		return true
+	}
+	return c.Chat().Type == message.EventReceiveGroupMessage && message.HasPermission(s.Permission, g.MinRole)
+}
+
+// Wrap 使用守卫包装 Handler，未通过的事件将被忽略
+func (g *Guard) Wrap(h Handler) Handler {
+	return func(bot *Bot, e message.Event) {
+		if g.Allow(e) {
+			h(bot, e)
+		}
+	}
+}
+
+// deny 拒绝时回复的消息
+func (g *Guard) deny() string {
+	if g.Message != "" {
+		return g.Message
+	}
+	return "权限不足"
+}
@@ -4,8 +4,125 @@ import (
 	"github.com/virzz/gomirai/message"
 )
 
-// EventHandler -
+// Handler 事件处理函数
+type Handler func(bot *Bot, e message.Event)
+
+// MessageHandler 消息处理函数
+type MessageHandler func(bot *Bot, chain message.Chain, sender message.Sender)
+
+// EventHandler 事件处理器
+// 零值可直接使用，通过 On* 方法注册处理函数后交由 Bot.UseHandler 使用
 type EventHandler struct {
-	privateMessageHandlers []func(bot *Bot, chain message.Chain, sender message.Sender)
-	groupMessageHandlers   []func(bot *Bot, chain message.Chain, sender message.Sender)
+	privateMessageHandlers []MessageHandler
+	groupMessageHandlers   []MessageHandler
+	tempMessageHandlers    []MessageHandler
+	eventHandlers          map[string][]Handler
+	middlewares            []Middleware
+}
+
+// OnFriendMessage 注册好友消息处理函数
+func (h *EventHandler) OnFriendMessage(f MessageHandler) {
+	h.privateMessageHandlers = append(h.privateMessageHandlers, f)
+}
+
+// OnGroupMessage 注册群消息处理函数
+func (h *EventHandler) OnGroupMessage(f MessageHandler) {
+	h.groupMessageHandlers = append(h.groupMessageHandlers, f)
+}
+
+// OnTempMessage 注册临时会话消息处理函数
+func (h *EventHandler) OnTempMessage(f MessageHandler) {
+	h.tempMessageHandlers = append(h.tempMessageHandlers, f)
+}
+
+// OnEvent 注册指定类型事件的处理函数
+// t 为 message 包中 Event* 常量之一
+func (h *EventHandler) OnEvent(t string, f Handler) {
+	if h.eventHandlers == nil {
+		h.eventHandlers = make(map[string][]Handler)
+	}
+	h.eventHandlers[t] = append(h.eventHandlers[t], f)
+}
+
+// OnMessage 注册处理所有消息（群、好友、临时会话）的处理函数
+func (h *EventHandler) OnMessage(f func(c *MessageContext)) {
+	handler := func(bot *Bot, e message.Event) {
+		f(NewMessageContext(bot, e.(message.ChatEvent)))
+	}
+	h.OnEvent(message.EventReceiveFriendMessage, handler)
+	h.OnEvent(message.EventReceiveGroupMessage, handler)
+	h.OnEvent(message.EventReceiveTempMessage, handler)
+}
+
+// OnMemberJoin 注册新人入群事件处理函数
+func (h *EventHandler) OnMemberJoin(f func(bot *Bot, e *message.MemberJoinEvent)) {
+	h.OnEvent(message.EventMemberJoin, func(bot *Bot, e message.Event) {
+		f(bot, e.(*message.MemberJoinEvent))
+	})
+}
+
+// OnMemberLeave 注册成员离群（主动退出及被踢出）事件处理函数
+// e 为 *message.MemberLeaveEventKick 或 *message.MemberLeaveEventQuit
+func (h *EventHandler) OnMemberLeave(f Handler) {
+	h.OnEvent(message.EventMemberLeaveKick, f)
+	h.OnEvent(message.EventMemberLeaveQuit, f)
+}
+
+// OnMemberJoinRequest 注册入群申请事件处理函数
+func (h *EventHandler) OnMemberJoinRequest(f func(bot *Bot, e *message.MemberJoinRequestEvent)) {
+	h.OnEvent(message.EventMemberJoinRequest, func(bot *Bot, e message.Event) {
+		f(bot, e.(*message.MemberJoinRequestEvent))
+	})
+}
+
+// OnNewFriendRequest 注册添加好友申请事件处理函数
+func (h *EventHandler) OnNewFriendRequest(f func(bot *Bot, e *message.NewFriendRequestEvent)) {
+	h.OnEvent(message.EventNewFriendRequest, func(bot *Bot, e message.Event) {
+		f(bot, e.(*message.NewFriendRequestEvent))
+	})
+}
+
+// OnBotInvitedJoinGroupRequest 注册Bot被邀请入群申请事件处理函数
+func (h *EventHandler) OnBotInvitedJoinGroupRequest(f func(bot *Bot, e *message.BotInvitedJoinGroupRequestEvent)) {
+	h.OnEvent(message.EventBotInvitedJoinGroupRequest, func(bot *Bot, e message.Event) {
+		f(bot, e.(*message.BotInvitedJoinGroupRequestEvent))
+	})
+}
+
+// Use 添加中间件，先添加的中间件位于外层
+func (h *EventHandler) Use(mw ...Middleware) {
+	h.middlewares = append(h.middlewares, mw...)
+}
+
+// Dispatch 将事件经过中间件后分发至已注册的处理函数
+// 消息事件先交由对应的 MessageHandler 处理，随后所有事件交由 OnEvent 注册的处理函数处理
+func (h *EventHandler) Dispatch(bot *Bot, e message.Event) {
+	handler := Handler(h.dispatch)
+	for i := len(h.middlewares) - 1; i >= 0; i-- {
+		handler = h.middlewares[i](handler)
+	}
+	handler(bot, e)
+}
+
+// dispatch 将事件分发至已注册的处理函数
+func (h *EventHandler) dispatch(bot *Bot, e message.Event) {
+	var handlers []MessageHandler
+	switch e.EventType() {
+	case message.EventReceiveFriendMessage:
+		handlers = h.privateMessageHandlers
+	case message.EventReceiveGroupMessage:
+		handlers = h.groupMessageHandlers
+	case message.EventReceiveTempMessage:
+		handlers = h.tempMessageHandlers
+	}
+	if c, ok := e.(message.ChatEvent); ok && len(handlers) > 0 {
+		m := c.Chat()
+		chain := message.GenChain(m.MessageChain...)
+		for _, f := range handlers {
+			f(bot, chain, m.Sender)
+		}
+	}
+	for _, f := range h.eventHandlers[e.EventType()] {
+		f(bot, e)
+	}
 }
@@ -0,0 +1,114 @@
+package gomirai
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 后端状态
+const (
+	// StatusUnknown 尚未进行心跳
+	StatusUnknown = iota
+	// StatusUp 后端可用
+	StatusUp
+	// StatusDown 后端不可用
+	StatusDown
+)
+
+// Heartbeat 心跳设置
+type Heartbeat struct {
+	// Interval 心跳间隔，默认为 1 分钟，应小于 Session 的过期时间（30分钟）
+	Interval time.Duration
+	// Timeout 单次心跳的超时时间，默认为 10 秒
+	Timeout time.Duration
+	// OnUp 后端变为可用时调用
+	OnUp func(bot *Bot)
+	// OnDown 后端变为不可用时调用
+	OnDown func(bot *Bot, err error)
+	// OnBeat 每次心跳成功后调用
+	OnBeat func(bot *Bot, latency time.Duration)
+}
+
+// heartbeatState 心跳状态
+type heartbeatState struct {
+	sync.Mutex
+	status  int
+	latency time.Duration
+}
+
+// StartHeartbeat 在后台定时请求 Mirai-api-http 以保持 Session 活跃，并测量延迟
+// Session 失效时会自动恢复，ctx 取消时停止
+func (b *Bot) StartHeartbeat(ctx context.Context, hb Heartbeat) {
+	if hb.Interval <= 0 {
+		hb.Interval = time.Minute
+	}
+	if hb.Timeout <= 0 {
+		hb.Timeout = 10 * time.Second
+	}
+	go func() {
+		t := time.NewTicker(hb.Interval)
+		defer t.Stop()
+		for {
+			b.beat(ctx, hb)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// beat 进行一次心跳
+func (b *Bot) beat(ctx context.Context, hb Heartbeat) {
+	ctx, cancel := context.WithTimeout(ctx, hb.Timeout)
+	defer cancel()
+	start := time.Now()
+	_, err := b.get(ctx, "/countMessage", nil)
+	latency := time.Since(start)
+	if ctx.Err() == context.Canceled {
+		return
+	}
+
+	status := StatusUp
+	if err != nil {
+		status = StatusDown
+	}
+	b.heartbeat.Lock()
+	prev := b.heartbeat.status
+	b.heartbeat.status = status
+	if err == nil {
+		b.heartbeat.latency = latency
+	}
+	b.heartbeat.Unlock()
+
+	if err != nil {
+		b.Logger.Warnln("Heartbeat Failed:", err)
+		if prev != StatusDown && hb.OnDown != nil {
+			hb.OnDown(b, err)
+		}
+		return
+	}
+	b.Logger.Traceln("Heartbeat", latency)
+	if prev != StatusUp && hb.OnUp != nil {
+		hb.OnUp(b)
+	}
+	if hb.OnBeat != nil {
+		hb.OnBeat(b, latency)
+	}
+}
+
+// Latency 最近一次成功心跳的延迟
+func (b *Bot) Latency() time.Duration {
+	b.heartbeat.Lock()
+	defer b.heartbeat.Unlock()
+	return b.heartbeat.latency
+}
+
+// Status 最近一次心跳得到的后端状态，为 StatusUnknown StatusUp StatusDown 之一
+func (b *Bot) Status() int {
+	b.heartbeat.Lock()
+	defer b.heartbeat.Unlock()
+	return b.heartbeat.status
+}
@@ -1,8 +1,16 @@
 package message
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // Chain 消息链
 type Chain struct {
 	Msg []Message
+	// Quote 引用消息id 0为不引用
+	Quote int64
 }
 
 // GenChain 生成消息链
@@ -11,3 +19,193 @@ func GenChain(args ...Message) Chain {
 		Msg: args,
 	}
 }
+
+// PlainText 见 Messages.PlainText
+func (c Chain) PlainText() string { return Messages(c.Msg).PlainText() }
+
+// SourceID 见 Messages.SourceID
+func (c Chain) SourceID() int64 { return Messages(c.Msg).SourceID() }
+
+// SourceTime 见 Messages.SourceTime
+func (c Chain) SourceTime() time.Time { return Messages(c.Msg).SourceTime() }
+
+// Mentions 见 Messages.Mentions
+func (c Chain) Mentions() []int64 { return Messages(c.Msg).Mentions() }
+
+// IsAtBot 见 Messages.IsAtBot
+func (c Chain) IsAtBot(qq int64) bool { return Messages(c.Msg).IsAtBot(qq) }
+
+// QuoteTarget 见 Messages.QuoteTarget
+func (c Chain) QuoteTarget() int64 { return Messages(c.Msg).QuoteTarget() }
+
+// StripMentions 见 Messages.StripMentions
+func (c Chain) StripMentions() Chain {
+	return Chain{Msg: Messages(c.Msg).StripMentions(), Quote: c.Quote}
+}
+
+// Images 见 Messages.Images
+func (c Chain) Images() []Message { return Messages(c.Msg).Images() }
+
+// Messages 消息列表，提供常用的查询方法
+type Messages []Message
+
+// PlainText 拼接所有文本消息
+func (ms Messages) PlainText() string {
+	var sb strings.Builder
+	for _, m := range ms {
+		if m.Type == MsgTypePlain {
+			sb.WriteString(m.Text)
+		}
+	}
+	return sb.String()
+}
+
+// SourceID 消息id，没有 Source 时返回 0
+func (ms Messages) SourceID() int64 {
+	for _, m := range ms {
+		if m.Type == MsgTypeSource {
+			return m.ID
+		}
+	}
+	return 0
+}
+
+// SourceTime 消息发送时间，没有 Source 时返回零值
+func (ms Messages) SourceTime() time.Time {
+	for _, m := range ms {
+		if m.Type == MsgTypeSource {
+			return time.Unix(m.Time, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// Mentions 所有被@的QQ号，@全体成员时包含 0
+func (ms Messages) Mentions() []int64 {
+	var qq []int64
+	for _, m := range ms {
+		switch m.Type {
+		case MsgTypeAt:
+			qq = append(qq, m.Target)
+		case MsgTypeAtAll:
+			qq = append(qq, 0)
+		}
+	}
+	return qq
+}
+
+// IsAtBot 是否@了指定QQ（通常为Bot）
+func (ms Messages) IsAtBot(qq int64) bool {
+	for _, m := range ms {
+		if m.Type == MsgTypeAt && m.Target == qq {
+			return true
+		}
+	}
+	return false
+}
+
+// QuoteTarget 被引用回复的原消息id，没有引用时返回 0
+func (ms Messages) QuoteTarget() int64 {
+	for _, m := range ms {
+		if m.Type == MsgTypeQuote {
+			return m.ID
+		}
+	}
+	return 0
+}
+
+// StripMentions 去除所有@及紧随其后文本的前导空白
+func (ms Messages) StripMentions() Messages {
+	r := make(Messages, 0, len(ms))
+	trim := false
+	for _, m := range ms {
+		switch m.Type {
+		case MsgTypeAt, MsgTypeAtAll:
+			trim = true
+			continue
+		case MsgTypePlain:
+			if trim {
+				m.Text = strings.TrimLeft(m.Text, " \t")
+				if m.Text == "" {
+					continue
+				}
+			}
+		}
+		trim = false
+		r = append(r, m)
+	}
+	return r
+}
+
+// Images 所有图片及闪照消息
+func (ms Messages) Images() []Message {
+	var imgs []Message
+	for _, m := range ms {
+		if m.Type == MsgTypeImage || m.Type == MsgTypeFlashImage {
+			imgs = append(imgs, m)
+		}
+	}
+	return imgs
+}
+
+// Builder 消息链构造器
+type Builder struct {
+	chain Chain
+}
+
+// NewBuilder 新建消息链构造器
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Append 添加任意消息
+func (b *Builder) Append(msg ...Message) *Builder {
+	b.chain.Msg = append(b.chain.Msg, msg...)
+	return b
+}
+
+// Text 添加文本
+func (b *Builder) Text(text string) *Builder {
+	return b.Append(PlainMessage(text))
+}
+
+// Textf 添加格式化文本
+func (b *Builder) Textf(format string, args ...interface{}) *Builder {
+	return b.Append(PlainMessage(fmt.Sprintf(format, args...)))
+}
+
+// At 添加@
+func (b *Builder) At(qq int64) *Builder {
+	return b.Append(Message{Type: MsgTypeAt, Target: qq})
+}
+
+// AtAll 添加@全体成员
+func (b *Builder) AtAll() *Builder {
+	return b.Append(Message{Type: MsgTypeAtAll})
+}
+
+// Face 添加表情
+func (b *Builder) Face(faceID int) *Builder {
+	return b.Append(FaceMessage(faceID))
+}
+
+// Image 添加图片，t 为 id url path 之一
+func (b *Builder) Image(t, v string) *Builder {
+	return b.Append(ImageMessage(t, v))
+}
+
+// Quote 引用回复指定消息
+func (b *Builder) Quote(id int64) *Builder {
+	b.chain.Quote = id
+	return b
+}
+
+// Newline 添加换行
+func (b *Builder) Newline() *Builder {
+	return b.Append(PlainMessage("\n"))
+}
+
+// Build 生成消息链
+func (b *Builder) Build() Chain {
+	return Chain{Msg: append([]Message(nil), b.chain.Msg...), Quote: b.chain.Quote}
+}
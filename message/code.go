@@ -0,0 +1,401 @@
+package message
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Mirai码 https://github.com/mamoe/mirai/blob/dev/docs/Messages.md#mirai-码
+// CQ码 https://github.com/botuniverse/onebot/blob/master/v11/specs/message/string.md
+
+// --- Mirai码 ---
+
+var (
+	miraiEscaper = strings.NewReplacer(
+		`\`, `\\`, `[`, `\[`, `]`, `\]`, `:`, `\:`, `,`, `\,`, "\n", `\n`, "\r", `\r`,
+	)
+	cqTextEscaper  = strings.NewReplacer("&", "&amp;", "[", "&#91;", "]", "&#93;")
+	cqParamEscaper = strings.NewReplacer("&", "&amp;", "[", "&#91;", "]", "&#93;", ",", "&#44;")
+	cqUnescaper    = strings.NewReplacer("&#44;", ",", "&#91;", "[", "&#93;", "]", "&amp;", "&")
+)
+
+// ParseMiraiCode 将 Mirai码 字符串解析为消息链
+// 无法识别的码原样保留为 MiraiCode 消息
+func ParseMiraiCode(s string) []Message {
+	var msgs []Message
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			msgs = append(msgs, PlainMessage(text.String()))
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			text.WriteString(miraiUnescape(s[i : i+2]))
+			i++
+		case strings.HasPrefix(s[i:], "[mirai:"):
+			end := indexUnescaped(s[i:], ']')
+			if end < 0 {
+				text.WriteString(miraiUnescape(s[i:]))
+				i = len(s)
+				continue
+			}
+			body := s[i+len("[mirai:") : i+end]
+			m, ok := parseMiraiCode(body)
+			if !ok {
+				m = MiraiCodeMessage(s[i : i+end+1])
+			}
+			flush()
+			msgs = append(msgs, m)
+			i += end
+		default:
+			text.WriteByte(s[i])
+		}
+	}
+	flush()
+	return msgs
+}
+
+// parseMiraiCode 解析单个 Mirai码，body 为 [mirai: 与 ] 之间的部分
+func parseMiraiCode(body string) (Message, bool) {
+	kind, rest := body, ""
+	if n := indexUnescaped(body, ':'); n >= 0 {
+		kind, rest = body[:n], body[n+1:]
+	}
+	var args []string
+	if rest != "" {
+		args = splitUnescaped(rest, ',')
+	}
+	arg := func(n int) string {
+		if n < len(args) {
+			return args[n]
+		}
+		return ""
+	}
+	num := func(n int) int64 {
+		v, _ := strconv.ParseInt(arg(n), 10, 64)
+		return v
+	}
+	switch kind {
+	case "at":
+		if num(0) <= 0 {
+			return Message{}, false
+		}
+		m := AtMessage(num(0))
+		m.Display = arg(1)
+		return m, true
+	case "atall":
+		return Message{Type: MsgTypeAtAll}, true
+	case "face":
+		id, err := strconv.Atoi(arg(0))
+		if err != nil {
+			return Message{}, false
+		}
+		return FaceMessage(id), true
+	case "image":
+		return ImageMessage("id", arg(0)), true
+	case "flash":
+		return FlashImageMessage("id", arg(0)), true
+	case "poke":
+		return PokeMessage(arg(0)), true
+	case "dice":
+		return DiceMessage(int(num(0))), true
+	case "marketface":
+		return MarketFaceMessage(num(0), arg(1)), true
+	case "app":
+		return RichMessage(MsgTypeApp, arg(0)), true
+	case "service":
+		if strings.HasPrefix(strings.TrimSpace(arg(1)), "<") {
+			return RichMessage(MsgTypeXML, arg(1)), true
+		}
+		return RichMessage(MsgTypeJSON, arg(1)), true
+	case "musicshare":
+		m := MusicShareMessage(arg(0), arg(1), arg(2), arg(3), arg(4), arg(5))
+		m.Brief = arg(6)
+		return m, true
+	case "file":
+		m := FileMessage(arg(0), arg(2), num(3))
+		m.InternalID = num(1)
+		return m, true
+	}
+	return Message{}, false
+}
+
+// ToMiraiCode 将消息链序列化为 Mirai码 字符串
+// Source、语音、无 ImageID 的图片等无法表示的消息将被忽略
+func ToMiraiCode(msgs []Message) string {
+	var sb strings.Builder
+	code := func(kind string, args ...string) {
+		sb.WriteString("[mirai:" + kind)
+		for i, a := range args {
+			if i == 0 {
+				sb.WriteByte(':')
+			} else {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(miraiEscaper.Replace(a))
+		}
+		sb.WriteByte(']')
+	}
+	for _, m := range msgs {
+		switch m.Type {
+		case MsgTypePlain:
+			sb.WriteString(miraiEscaper.Replace(m.Text))
+		case MsgTypeAt:
+			code("at", strconv.FormatInt(m.Target, 10))
+		case MsgTypeAtAll:
+			code("atall")
+		case MsgTypeFace:
+			code("face", strconv.Itoa(m.FaceID))
+		case MsgTypeImage:
+			if m.ImageID != "" {
+				code("image", m.ImageID)
+			}
+		case MsgTypeFlashImage:
+			if m.ImageID != "" {
+				code("flash", m.ImageID)
+			}
+		case MsgTypePoke:
+			code("poke", m.Name)
+		case MsgTypeDice:
+			code("dice", strconv.Itoa(m.Value))
+		case MsgTypeMarketFace:
+			code("marketface", strconv.FormatInt(m.ID, 10), m.Name)
+		case MsgTypeApp:
+			code("app", m.Content)
+		case MsgTypeXML:
+			code("service", "60", m.XML)
+		case MsgTypeJSON:
+			code("service", "1", m.JSON)
+		case MsgTypeMusicShare:
+			code("musicshare", m.Kind, m.Title, m.Summary, m.JumpURL, m.PictureURL, m.MusicURL, m.Brief)
+		case MsgTypeFile:
+			code("file", m.FileID, strconv.FormatInt(m.InternalID, 10), m.Name, strconv.FormatInt(m.Size, 10))
+		case MsgTypeMiraiCode:
+			sb.WriteString(m.Code)
+		}
+	}
+	return sb.String()
+}
+
+// miraiUnescape 还原 Mirai码 转义
+func miraiUnescape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// indexUnescaped 返回 s 中第一个未被 \ 转义的 c 的位置
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitUnescaped 以未被转义的 sep 分割 s 并还原转义
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	for {
+		n := indexUnescaped(s, sep)
+		if n < 0 {
+			return append(parts, miraiUnescape(s))
+		}
+		parts = append(parts, miraiUnescape(s[:n]))
+		s = s[n+1:]
+	}
+}
+
+// --- CQ码 ---
+
+// ParseCQCode 将 CQ码 字符串解析为消息链
+// 无法识别的码原样保留为 MiraiCode 消息，由 ToCQCode 原样输出
+func ParseCQCode(s string) []Message {
+	var msgs []Message
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			msgs = append(msgs, PlainMessage(cqUnescaper.Replace(text.String())))
+			text.Reset()
+		}
+	}
+	for len(s) > 0 {
+		start := strings.Index(s, "[CQ:")
+		if start < 0 {
+			text.WriteString(s)
+			break
+		}
+		end := strings.IndexByte(s[start:], ']')
+		if end < 0 {
+			text.WriteString(s)
+			break
+		}
+		text.WriteString(s[:start])
+		raw := s[start : start+end+1]
+		m, ok := parseCQCode(raw[len("[CQ:") : len(raw)-1])
+		if !ok {
+			m = MiraiCodeMessage(raw)
+		}
+		flush()
+		msgs = append(msgs, m)
+		s = s[start+end+1:]
+	}
+	flush()
+	return msgs
+}
+
+// parseCQCode 解析单个 CQ码，body 为 [CQ: 与 ] 之间的部分
+func parseCQCode(body string) (Message, bool) {
+	parts := strings.Split(body, ",")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if n := strings.IndexByte(p, '='); n >= 0 {
+			params[p[:n]] = cqUnescaper.Replace(p[n+1:])
+		}
+	}
+	num := func(key string) int64 {
+		v, _ := strconv.ParseInt(params[key], 10, 64)
+		return v
+	}
+	file := func(m Message) Message {
+		switch f := params["file"]; {
+		case params["url"] != "":
+			m.ImageURL = params["url"]
+		case strings.HasPrefix(f, "http://") || strings.HasPrefix(f, "https://"):
+			m.ImageURL = f
+		case strings.HasPrefix(f, "file://"):
+			m.ImagePath = strings.TrimPrefix(f, "file://")
+		case m.Type == MsgTypeVoice:
+			m.VoiceID = f
+		default:
+			m.ImageID = f
+		}
+		return m
+	}
+	switch parts[0] {
+	case "at":
+		if params["qq"] == "all" {
+			return Message{Type: MsgTypeAtAll}, true
+		}
+		if num("qq") <= 0 {
+			return Message{}, false
+		}
+		return AtMessage(num("qq")), true
+	case "face":
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			return Message{}, false
+		}
+		return FaceMessage(id), true
+	case "image":
+		if params["type"] == "flash" {
+			return file(Message{Type: MsgTypeFlashImage}), true
+		}
+		return file(Message{Type: MsgTypeImage}), true
+	case "record":
+		return file(Message{Type: MsgTypeVoice}), true
+	case "reply":
+		return Message{Type: MsgTypeQuote, ID: num("id")}, true
+	case "dice":
+		return DiceMessage(int(num("result"))), true
+	case "poke":
+		return PokeMessage(params["name"]), true
+	case "xml":
+		return RichMessage(MsgTypeXML, params["data"]), true
+	case "json":
+		return RichMessage(MsgTypeJSON, params["data"]), true
+	case "music":
+		if params["type"] != "custom" {
+			return Message{}, false
+		}
+		return MusicShareMessage(params["subtype"], params["title"], params["content"], params["url"], params["image"], params["audio"]), true
+	}
+	return Message{}, false
+}
+
+// ToCQCode 将消息链序列化为 CQ码 字符串
+// Source、语音、无 ImageID 的图片等无法表示的消息将被忽略
+func ToCQCode(msgs []Message) string {
+	var sb strings.Builder
+	code := func(kind string, params ...string) {
+		sb.WriteString("[CQ:" + kind)
+		for i := 0; i+1 < len(params); i += 2 {
+			if params[i+1] == "" {
+				continue
+			}
+			sb.WriteString("," + params[i] + "=" + cqParamEscaper.Replace(params[i+1]))
+		}
+		sb.WriteByte(']')
+	}
+	file := func(m Message) string {
+		switch {
+		case m.ImageID != "":
+			return m.ImageID
+		case m.VoiceID != "":
+			return m.VoiceID
+		case m.ImageURL != "":
+			return m.ImageURL
+		case m.ImagePath != "":
+			return "file://" + m.ImagePath
+		}
+		return ""
+	}
+	for _, m := range msgs {
+		switch m.Type {
+		case MsgTypePlain:
+			sb.WriteString(cqTextEscaper.Replace(m.Text))
+		case MsgTypeAt:
+			code("at", "qq", strconv.FormatInt(m.Target, 10))
+		case MsgTypeAtAll:
+			code("at", "qq", "all")
+		case MsgTypeFace:
+			code("face", "id", strconv.Itoa(m.FaceID))
+		case MsgTypeImage:
+			code("image", "file", file(m))
+		case MsgTypeFlashImage:
+			code("image", "file", file(m), "type", "flash")
+		case MsgTypeVoice:
+			code("record", "file", file(m))
+		case MsgTypeQuote:
+			code("reply", "id", strconv.FormatInt(m.ID, 10))
+		case MsgTypeDice:
+			code("dice", "result", strconv.Itoa(m.Value))
+		case MsgTypePoke:
+			code("poke", "name", m.Name)
+		case MsgTypeXML:
+			code("xml", "data", m.XML)
+		case MsgTypeJSON:
+			code("json", "data", m.JSON)
+		case MsgTypeMusicShare:
+			code("music", "type", "custom", "subtype", m.Kind, "url", m.JumpURL, "audio", m.MusicURL,
+				"title", m.Title, "content", m.Summary, "image", m.PictureURL)
+		case MsgTypeMiraiCode:
+			if strings.HasPrefix(m.Code, "[CQ:") {
+				sb.WriteString(m.Code)
+			} else {
+				sb.WriteString(cqTextEscaper.Replace(m.Code))
+			}
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package message
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMiraiCodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		msgs []Message
+	}{
+		{"plain", "hello", []Message{PlainMessage("hello")}},
+		{"escaped", `a\[b\]\:\,\\\n`, []Message{PlainMessage("a[b]:,\\\n")}},
+		{"at", "hi [mirai:at:123]", []Message{PlainMessage("hi "), AtMessage(123)}},
+		{"atall", "[mirai:atall]", []Message{{Type: MsgTypeAtAll}}},
+		{"face", "[mirai:face:14]", []Message{FaceMessage(14)}},
+		{"image", "[mirai:image:{01E9451B-70ED-EAE3-B37C-101F1EEBF5B5}.jpg]", []Message{ImageMessage("id", "{01E9451B-70ED-EAE3-B37C-101F1EEBF5B5}.jpg")}},
+		{"flash", "[mirai:flash:abc.jpg]", []Message{FlashImageMessage("id", "abc.jpg")}},
+		{"poke", "[mirai:poke:Poke]", []Message{PokeMessage("Poke")}},
+		{"dice", "[mirai:dice:5]", []Message{DiceMessage(5)}},
+		{"marketface", "[mirai:marketface:1,\\[表情\\]]", []Message{MarketFaceMessage(1, "[表情]")}},
+		{"unknown", "a[mirai:unknown:1]b", []Message{PlainMessage("a"), MiraiCodeMessage("[mirai:unknown:1]"), PlainMessage("b")}},
+		{"at zero", "[mirai:at:0]", []Message{MiraiCodeMessage("[mirai:at:0]")}},
+		{"at invalid", "[mirai:at:abc]", []Message{MiraiCodeMessage("[mirai:at:abc]")}},
+		{"face invalid", "[mirai:face:abc]", []Message{MiraiCodeMessage("[mirai:face:abc]")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMiraiCode(tt.code)
+			if !reflect.DeepEqual(got, tt.msgs) {
+				t.Fatalf("ParseMiraiCode(%q) = %+v, want %+v", tt.code, got, tt.msgs)
+			}
+			if s := ToMiraiCode(got); s != tt.code {
+				t.Errorf("ToMiraiCode() = %q, want %q", s, tt.code)
+			}
+		})
+	}
+}
+
+func TestCQCodeRoundTrip(t *testing.T) {
+	image := ImageMessage("id", "abc.jpg")
+	flash := FlashImageMessage("id", "abc.jpg")
+	tests := []struct {
+		name string
+		code string
+		msgs []Message
+	}{
+		{"plain", "hello", []Message{PlainMessage("hello")}},
+		{"escaped", "&#91;a&#93;&amp;", []Message{PlainMessage("[a]&")}},
+		{"at", "hi [CQ:at,qq=123]", []Message{PlainMessage("hi "), AtMessage(123)}},
+		{"atall", "[CQ:at,qq=all]", []Message{{Type: MsgTypeAtAll}}},
+		{"face", "[CQ:face,id=14]", []Message{FaceMessage(14)}},
+		{"image", "[CQ:image,file=abc.jpg]", []Message{image}},
+		{"flash", "[CQ:image,file=abc.jpg,type=flash]", []Message{flash}},
+		{"reply", "[CQ:reply,id=7]", []Message{{Type: MsgTypeQuote, ID: 7}}},
+		{"dice", "[CQ:dice,result=5]", []Message{DiceMessage(5)}},
+		{"poke", "[CQ:poke,name=Poke]", []Message{PokeMessage("Poke")}},
+		{"unknown", "a[CQ:shake]b", []Message{PlainMessage("a"), MiraiCodeMessage("[CQ:shake]"), PlainMessage("b")}},
+		{"at zero", "[CQ:at,qq=0]", []Message{MiraiCodeMessage("[CQ:at,qq=0]")}},
+		{"face invalid", "[CQ:face,id=abc]", []Message{MiraiCodeMessage("[CQ:face,id=abc]")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseCQCode(tt.code)
+			if !reflect.DeepEqual(got, tt.msgs) {
+				t.Fatalf("ParseCQCode(%q) = %+v, want %+v", tt.code, got, tt.msgs)
+			}
+			if s := ToCQCode(got); s != tt.code {
+				t.Errorf("ToCQCode() = %q, want %q", s, tt.code)
+			}
+		})
+	}
+}
+
+func TestMiraiCodeToCQCode(t *testing.T) {
+	msgs := ParseMiraiCode("[mirai:at:123] roll [mirai:dice:3]")
+	want := "[CQ:at,qq=123] roll [CQ:dice,result=3]"
+	if s := ToCQCode(msgs); s != want {
+		t.Errorf("ToCQCode() = %q, want %q", s, want)
+	}
+}
+
+func TestToMiraiCodeSkipsUnrepresentable(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"image url", "a[CQ:image,file=http://a/b.jpg]b", "ab"},
+		{"flash path", "[CQ:image,file=file:///tmp/a.jpg,type=flash]", ""},
+		{"voice", "[CQ:record,file=abc.amr]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := ToMiraiCode(ParseCQCode(tt.code)); s != tt.want {
+				t.Errorf("ToMiraiCode(ParseCQCode(%q)) = %q, want %q", tt.code, s, tt.want)
+			}
+		})
+	}
+}
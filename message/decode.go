@@ -0,0 +1,58 @@
+package message
+
+import "encoding/json"
+
+// eventTypes 事件类型与对应结构体
+var eventTypes = map[string]func() Event{
+	EventBotOnline:                       func() Event { return &BotOnlineEvent{} },
+	EventBotOfflineActive:                func() Event { return &BotOfflineEventActive{} },
+	EventBotOfflineForce:                 func() Event { return &BotOfflineEventForce{} },
+	EventBotOfflineDropped:               func() Event { return &BotOfflineEventDropped{} },
+	EventBotRelogin:                      func() Event { return &BotReloginEvent{} },
+	EventBotGroupPermissionChange:        func() Event { return &BotGroupPermissionChangeEvent{} },
+	EventBotMute:                         func() Event { return &BotMuteEvent{} },
+	EventBotUnmute:                       func() Event { return &BotUnmuteEvent{} },
+	EventBotJoinGroup:                    func() Event { return &BotJoinGroupEvent{} },
+	EventBotLeaveActive:                  func() Event { return &BotLeaveEventActive{} },
+	EventBotLeaveKick:                    func() Event { return &BotLeaveEventKick{} },
+	EventReceiveFriendMessage:            func() Event { return &FriendMessage{} },
+	EventReceiveGroupMessage:             func() Event { return &GroupMessage{} },
+	EventReceiveTempMessage:              func() Event { return &TempMessage{} },
+	EventGroupNameChange:                 func() Event { return &GroupNameChangeEvent{} },
+	EventGroupEntranceAnnouncementChange: func() Event { return &GroupEntranceAnnouncementChangeEvent{} },
+	EventGroupAllowAnonymousChat:         func() Event { return &GroupAllowAnonymousChatEvent{} },
+	EventGroupAllowConfessTalk:           func() Event { return &GroupAllowConfessTalkEvent{} },
+	EventGroupAllowMemberInvite:          func() Event { return &GroupAllowMemberInviteEvent{} },
+	EventMemberJoinRequest:               func() Event { return &MemberJoinRequestEvent{} },
+	EventMemberJoin:                      func() Event { return &MemberJoinEvent{} },
+	EventMemberLeaveKick:                 func() Event { return &MemberLeaveEventKick{} },
+	EventMemberLeaveQuit:                 func() Event { return &MemberLeaveEventQuit{} },
+	EventGroupMuteAll:                    func() Event { return &GroupMuteAllEvent{} },
+	EventGroupRecall:                     func() Event { return &GroupRecallEvent{} },
+	EventMemberMute:                      func() Event { return &MemberMuteEvent{} },
+	EventMemberUnMute:                    func() Event { return &MemberUnmuteEvent{} },
+	EventMemberCardChange:                func() Event { return &MemberCardChangeEvent{} },
+	EventMemberSpecialTitleChange:        func() Event { return &MemberSpecialTitleChangeEvent{} },
+	EventMemberPermissionChange:          func() Event { return &MemberPermissionChangeEvent{} },
+	EventBotInvitedJoinGroupRequest:      func() Event { return &BotInvitedJoinGroupRequestEvent{} },
+	EventNewFriendRequest:                func() Event { return &NewFriendRequestEvent{} },
+	EventFriendRecall:                    func() Event { return &FriendRecallEvent{} },
+}
+
+// DecodeEvent 根据 type 字段将 JSON 解析为对应类型的事件
+// 未知类型的事件解析为 *UnknownEvent
+func DecodeEvent(data []byte) (Event, error) {
+	var base EventBase
+	if err := json.Unmarshal(data, &base); err != nil {
+		return nil, err
+	}
+	newEvent, ok := eventTypes[base.Type]
+	if !ok {
+		return &UnknownEvent{EventBase: base, Raw: append(json.RawMessage(nil), data...)}, nil
+	}
+	e := newEvent()
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
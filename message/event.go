@@ -1,5 +1,7 @@
 package message
 
+import "encoding/json"
+
 // Mirai-api-http事件类型一览
 // https://github.com/project-mirai/mirai-api-http/blob/master/EventType.md
 
@@ -129,8 +131,8 @@ type Group struct {
 	ID int64 `json:"id,omitempty"`
 	// Name 消息来源群名
 	Name string `json:"name,omitempty"`
-	// Permisson bot在群中的角色
-	Permisson string `json:"permisson,omitempty"`
+	// Permisson bot在群中的角色（字段名保留原有拼写以保持兼容）
+	Permisson string `json:"permission,omitempty"`
 }
 
 // Friend -
@@ -154,41 +156,348 @@ type Sender struct {
 	Group Group `json:"group"`
 }
 
-// ComplexEvent -
-type ComplexEvent struct {
-	// Base
+// Event 事件
+// 具体类型为本包中与事件类型同名的结构体指针，如 *GroupMessage、*MemberJoinEvent
+type Event interface {
+	// EventType 事件类型，为 Event* 常量之一
+	EventType() string
+}
+
+// EventBase 事件共有字段
+type EventBase struct {
 	Type string `json:"type"`
-	// Message
-	MessageChain []Message `json:"messageChain"`
-	// Sender
-	Sender Sender `json:"sender"`
-	// Common
-	Member   Member   `json:"member"`
+}
+
+// EventType 返回事件类型
+func (e EventBase) EventType() string {
+	return e.Type
+}
+
+// StringChange 字符串类型的设置变更 (群名、入群公告、群名片、群头衔、权限)
+type StringChange struct {
+	// Origin 原值
+	Origin string `json:"origin"`
+	// New 新值
+	New string `json:"new"`
+	// Current 当前值
+	Current string `json:"current"`
+}
+
+// BoolChange 开关类型的设置变更 (全员禁言、匿名聊天、坦白说、允许邀请)
+type BoolChange struct {
+	// Origin 原值
+	Origin bool `json:"origin"`
+	// New 新值
+	New bool `json:"new"`
+	// Current 当前值
+	Current bool `json:"current"`
+}
+
+// ChatEvent 消息事件 (FriendMessage GroupMessage TempMessage)
+type ChatEvent interface {
+	Event
+	// Chat 返回消息事件的公共部分
+	Chat() *MessageEvent
+}
+
+// MessageEvent 消息事件公共部分
+type MessageEvent struct {
+	EventBase
+	MessageChain Messages `json:"messageChain"`
+	Sender       Sender   `json:"sender"`
+}
+
+// Chat 返回消息事件的公共部分
+func (e *MessageEvent) Chat() *MessageEvent {
+	return e
+}
+
+// FriendMessage 好友消息
+type FriendMessage struct {
+	MessageEvent
+}
+
+// GroupMessage 群组消息
+type GroupMessage struct {
+	MessageEvent
+}
+
+// TempMessage 临时消息
+type TempMessage struct {
+	MessageEvent
+}
+
+// BotOnlineEvent Bot登录成功
+type BotOnlineEvent struct {
+	EventBase
+	QQ int64 `json:"qq"`
+}
+
+// BotOfflineEventActive Bot主动离线
+type BotOfflineEventActive struct {
+	EventBase
+	QQ int64 `json:"qq"`
+}
+
+// BotOfflineEventForce Bot被挤下线
+type BotOfflineEventForce struct {
+	EventBase
+	QQ int64 `json:"qq"`
+}
+
+// BotOfflineEventDropped Bot被服务器断开或因网络问题而掉线
+type BotOfflineEventDropped struct {
+	EventBase
+	QQ int64 `json:"qq"`
+}
+
+// BotReloginEvent Bot主动重新登录
+type BotReloginEvent struct {
+	EventBase
+	QQ int64 `json:"qq"`
+}
+
+// BotGroupPermissionChangeEvent Bot在群里的权限被改变. 操作人一定是群主
+type BotGroupPermissionChangeEvent struct {
+	EventBase
+	StringChange
+	Group Group `json:"group"`
+}
+
+// BotMuteEvent Bot被禁言
+type BotMuteEvent struct {
+	EventBase
+	DurationSeconds int      `json:"durationSeconds"`
+	Operator        Operator `json:"operator"`
+}
+
+// BotUnmuteEvent Bot被取消禁言
+type BotUnmuteEvent struct {
+	EventBase
 	Operator Operator `json:"operator"`
-	Group    Group    `json:"group"`
-	// Card | SpecialTitle
-	Origin  string
-	New     string
-	Current string
-	// TODO: 强烈建议 MiraiHttpApi 修改 Origin,New,Current 为 string
-	// // GroupMute
-	// Origin  bool
-	// New     bool
-	// Current bool
-	// GroupRecall
+}
+
+// BotJoinGroupEvent Bot加入了一个新群
+type BotJoinGroupEvent struct {
+	EventBase
+	Group Group `json:"group"`
+}
+
+// BotLeaveEventActive Bot主动退出一个群
+type BotLeaveEventActive struct {
+	EventBase
+	Group Group `json:"group"`
+}
+
+// BotLeaveEventKick Bot被踢出一个群
+type BotLeaveEventKick struct {
+	EventBase
+	Group Group `json:"group"`
+}
+
+// GroupRecallEvent 群消息撤回
+type GroupRecallEvent struct {
+	EventBase
+	AuthorID  int64    `json:"authorId"`
+	MessageID int64    `json:"messageId"`
+	Time      int64    `json:"time"`
+	Group     Group    `json:"group"`
+	Operator  Operator `json:"operator"`
+}
+
+// FriendRecallEvent 好友消息撤回
+type FriendRecallEvent struct {
+	EventBase
 	AuthorID  int64 `json:"authorId"`
 	MessageID int64 `json:"messageId"`
 	Time      int64 `json:"time"`
-	// MemberMuteEvent
-	DurationSeconds int `json:"durationSeconds"`
+	// Operator 撤回消息者的QQ号
+	Operator int64 `json:"operator"`
+}
+
+// GroupNameChangeEvent 某个群名改变
+type GroupNameChangeEvent struct {
+	EventBase
+	StringChange
+	Group    Group    `json:"group"`
+	Operator Operator `json:"operator"`
+}
+
+// GroupEntranceAnnouncementChangeEvent 某群入群公告改变
+type GroupEntranceAnnouncementChangeEvent struct {
+	EventBase
+	StringChange
+	Group    Group    `json:"group"`
+	Operator Operator `json:"operator"`
+}
 
-	// MemberJoinRequestEvent
-	EventID   int64  `json:"eventId"`
-	FromID    int64  `json:"fromId"`
-	GroupID   int64  `json:"groupId"`
+// GroupMuteAllEvent 全员禁言
+type GroupMuteAllEvent struct {
+	EventBase
+	BoolChange
+	Group    Group    `json:"group"`
+	Operator Operator `json:"operator"`
+}
+
+// GroupAllowAnonymousChatEvent 匿名聊天
+type GroupAllowAnonymousChatEvent struct {
+	EventBase
+	BoolChange
+	Group    Group    `json:"group"`
+	Operator Operator `json:"operator"`
+}
+
+// GroupAllowConfessTalkEvent 坦白说
+type GroupAllowConfessTalkEvent struct {
+	EventBase
+	BoolChange
+	Group   Group `json:"group"`
+	IsByBot bool  `json:"isByBot"`
+}
+
+// GroupAllowMemberInviteEvent 允许群员邀请好友加群
+type GroupAllowMemberInviteEvent struct {
+	EventBase
+	BoolChange
+	Group    Group    `json:"group"`
+	Operator Operator `json:"operator"`
+}
+
+// MemberJoinEvent 新人入群的事件
+type MemberJoinEvent struct {
+	EventBase
+	Member Member `json:"member"`
+}
+
+// MemberLeaveEventKick 成员被踢出群（该成员不是Bot）
+type MemberLeaveEventKick struct {
+	EventBase
+	Member   Member   `json:"member"`
+	Operator Operator `json:"operator"`
+}
+
+// MemberLeaveEventQuit 成员主动离群（该成员不是Bot）
+type MemberLeaveEventQuit struct {
+	EventBase
+	Member Member `json:"member"`
+}
+
+// MemberCardChangeEvent 群名片改动
+type MemberCardChangeEvent struct {
+	EventBase
+	StringChange
+	Member   Member   `json:"member"`
+	Operator Operator `json:"operator"`
+}
+
+// MemberSpecialTitleChangeEvent 群头衔改动（只有群主有操作限权）
+type MemberSpecialTitleChangeEvent struct {
+	EventBase
+	StringChange
+	Member Member `json:"member"`
+}
+
+// MemberPermissionChangeEvent 成员权限改变的事件（该成员不可能是Bot，见BotGroupPermissionChangeEvent）
+type MemberPermissionChangeEvent struct {
+	EventBase
+	StringChange
+	Member Member `json:"member"`
+}
+
+// MemberMuteEvent 群成员被禁言事件（该成员不可能是Bot，见BotMuteEvent）
+type MemberMuteEvent struct {
+	EventBase
+	DurationSeconds int      `json:"durationSeconds"`
+	Member          Member   `json:"member"`
+	Operator        Operator `json:"operator"`
+}
+
+// MemberUnmuteEvent 群成员被取消禁言事件（该成员不可能是Bot，见BotUnmuteEvent）
+type MemberUnmuteEvent struct {
+	EventBase
+	Member   Member   `json:"member"`
+	Operator Operator `json:"operator"`
+}
+
+// RequestEvent 申请事件公共部分
+type RequestEvent struct {
+	EventBase
+	// EventID 事件标识，响应该事件时的标识
+	EventID int64 `json:"eventId"`
+	// FromID 申请人QQ号
+	FromID int64 `json:"fromId"`
+	// GroupID 申请人所在群或被邀请的群，为0时表示不是通过群申请
+	GroupID int64 `json:"groupId"`
+	// Nick 申请人的昵称或群名片
+	Nick string `json:"nick"`
+	// Message 申请消息
+	Message string `json:"message"`
+}
+
+// NewFriendRequestEvent 添加好友申请
+type NewFriendRequestEvent struct {
+	RequestEvent
+}
+
+// MemberJoinRequestEvent 用户入群申请（Bot需要有管理员权限）
+type MemberJoinRequestEvent struct {
+	RequestEvent
+	GroupName string `json:"groupName"`
+}
+
+// BotInvitedJoinGroupRequestEvent Bot被邀请入群申请
+type BotInvitedJoinGroupRequestEvent struct {
+	RequestEvent
 	GroupName string `json:"groupName"`
-	Nickname  string `json:"nick"`
-	Message   string `json:"message"`
+}
+
+// RequestResponder 响应申请事件，*gomirai.Bot 实现了该接口
+type RequestResponder interface {
+	RespondRequest(e Event, approve bool, message string) error
+}
+
+// Approve 同意添加好友
+func (e *NewFriendRequestEvent) Approve(r RequestResponder) error {
+	return r.RespondRequest(e, true, "")
+}
+
+// Reject 拒绝添加好友，message 为回复的消息
+func (e *NewFriendRequestEvent) Reject(r RequestResponder, message string) error {
+	return r.RespondRequest(e, false, message)
+}
+
+// Approve 同意入群
+func (e *MemberJoinRequestEvent) Approve(r RequestResponder) error {
+	return r.RespondRequest(e, true, "")
+}
+
+// Reject 拒绝入群，message 为回复的消息
+func (e *MemberJoinRequestEvent) Reject(r RequestResponder, message string) error {
+	return r.RespondRequest(e, false, message)
+}
+
+// Approve 同意邀请
+func (e *BotInvitedJoinGroupRequestEvent) Approve(r RequestResponder) error {
+	return r.RespondRequest(e, true, "")
+}
+
+// Reject 拒绝邀请，message 为回复的消息
+func (e *BotInvitedJoinGroupRequestEvent) Reject(r RequestResponder, message string) error {
+	return r.RespondRequest(e, false, message)
+}
+
+// UnknownEvent 未知类型的事件，保留原始数据
+type UnknownEvent struct {
+	EventBase
+	Raw json.RawMessage `json:"-"`
+}
+
+// MarshalJSON 输出原始数据
+func (e *UnknownEvent) MarshalJSON() ([]byte, error) {
+	if e.Raw == nil {
+		return json.Marshal(e.EventBase)
+	}
+	return e.Raw, nil
 }
 
 // GroupConfig -
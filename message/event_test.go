@@ -0,0 +1,67 @@
+package message
+
+import "testing"
+
+func TestDecodeGroupPermission(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		role      int
+		botRole   int
+		groupName string
+	}{
+		{
+			name: "GroupMessage",
+			payload: `{"type":"GroupMessage","messageChain":[{"type":"Source","id":123,"time":1600000000},{"type":"Plain","text":"hi"}],
+				"sender":{"id":10001,"memberName":"member","permission":"MEMBER",
+				"group":{"id":20001,"name":"group","permission":"ADMINISTRATOR"}}}`,
+			role:      MEMBER,
+			botRole:   ADMINISTRATOR,
+			groupName: "group",
+		},
+		{
+			name: "TempMessage",
+			payload: `{"type":"TempMessage","messageChain":[],
+				"sender":{"id":10002,"memberName":"owner","permission":"OWNER",
+				"group":{"id":20002,"name":"other","permission":"MEMBER"}}}`,
+			role:      OWNER,
+			botRole:   MEMBER,
+			groupName: "other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := DecodeEvent([]byte(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, ok := e.(ChatEvent)
+			if !ok {
+				t.Fatalf("got %T, want ChatEvent", e)
+			}
+			s := c.Chat().Sender
+			if s.Role() != tt.role {
+				t.Errorf("Sender.Role() = %d, want %d", s.Role(), tt.role)
+			}
+			if s.Group.BotRole() != tt.botRole {
+				t.Errorf("Group.BotRole() = %d, want %d", s.Group.BotRole(), tt.botRole)
+			}
+			if s.Group.Name != tt.groupName {
+				t.Errorf("Group.Name = %q, want %q", s.Group.Name, tt.groupName)
+			}
+		})
+	}
+}
+
+func TestDecodeMemberGroupPermission(t *testing.T) {
+	payload := `{"type":"MemberJoinEvent","member":{"id":10003,"memberName":"new","permission":"MEMBER",
+		"group":{"id":20003,"name":"group","permission":"OWNER"}}}`
+	e, err := DecodeEvent([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := e.(*MemberJoinEvent).Member
+	if m.Role() != MEMBER || m.Group.BotRole() != OWNER {
+		t.Errorf("Role() = %d, BotRole() = %d", m.Role(), m.Group.BotRole())
+	}
+}
@@ -1,5 +1,7 @@
 package message
 
+import "encoding/json"
+
 const (
 	// MsgTypeSource -
 	MsgTypeSource = "Source"
@@ -25,12 +27,34 @@ const (
 	MsgTypeApp = "App"
 	// MsgTypePoke 戳一戳
 	MsgTypePoke = "Poke"
+	// MsgTypeVoice 语音
+	MsgTypeVoice = "Voice"
+	// MsgTypeForward 合并转发
+	MsgTypeForward = "Forward"
+	// MsgTypeFile 文件
+	MsgTypeFile = "File"
+	// MsgTypeMusicShare 音乐分享
+	MsgTypeMusicShare = "MusicShare"
+	// MsgTypeDice 骰子
+	MsgTypeDice = "Dice"
+	// MsgTypeMarketFace 商城表情
+	MsgTypeMarketFace = "MarketFace"
+	// MsgTypeMiraiCode Mirai码
+	MsgTypeMiraiCode = "MiraiCode"
 )
 
+// knownTypes 已知的消息类型，其余类型保留原始数据
+var knownTypes = map[string]bool{
+	MsgTypeSource: true, MsgTypeQuote: true, MsgTypeAt: true, MsgTypeAtAll: true, MsgTypeFace: true,
+	MsgTypePlain: true, MsgTypeImage: true, MsgTypeFlashImage: true, MsgTypeXML: true, MsgTypeJSON: true,
+	MsgTypeApp: true, MsgTypePoke: true, MsgTypeVoice: true, MsgTypeForward: true, MsgTypeFile: true,
+	MsgTypeMusicShare: true, MsgTypeDice: true, MsgTypeMarketFace: true, MsgTypeMiraiCode: true,
+}
+
 // Message 消息
 type Message struct {
 	Type string `json:"type,omitempty"`
-	ID   int64  `json:"id,omitempty"`   //(Source,Quote)Source中表示消息id，Quote中表示被引用回复的原消息的id
+	ID   int64  `json:"id,omitempty"`   //(Source,Quote,MarketFace)Source中表示消息id，Quote中表示被引用回复的原消息的id，MarketFace中表示表情id
 	Time int64  `json:"time,omitempty"` //(Source) 发送时间
 
 	GroupID  int64     `json:"groupId,omitempty"`  //(Quote)Quote中表示被引用回复的原消息的群号
@@ -42,17 +66,98 @@ type Message struct {
 	Display string `json:"display,omitempty"` //(At)@的显示文本
 
 	FaceID int    `json:"faceId,omitempty"` //(Face)QQ表情的ID,发送时优先级比Name高
-	Name   string `json:"name,omitempty"`   //(Face,Poke)Face中为QQ表情的拼音,Poke中为戳一戳的类型
+	Name   string `json:"name,omitempty"`   //(Face,Poke,File,MarketFace)Face中为QQ表情的拼音,Poke中为戳一戳的类型,File中为文件名,MarketFace中为表情名
 
 	Text string `json:"text,omitempty"` //(Plain)纯文本
 
 	ImageID   string `json:"imageId,omitempty"` //(Image,FlashImage)图片ID，注意消息类型，群图片和好友图片格式不一样，发送时优先级比ImageUrl高
-	ImageURL  string `json:"url,omitempty"`     //(Image,FlashImage)图片url,发送时可使用网络图片的链接，优先级比ImagePath高；接收时为腾讯图片服务器的链接
-	ImagePath string `json:"path,omitempty"`    //(Image,FlashImage)图片的路径，发送本地图片，相对路径于plugins/MiraiAPIHTTP/images
+	ImageURL  string `json:"url,omitempty"`     //(Image,FlashImage,Voice)图片或语音url,发送时可使用网络图片的链接，优先级比ImagePath高；接收时为腾讯图片服务器的链接
+	ImagePath string `json:"path,omitempty"`    //(Image,FlashImage,Voice)图片或语音的路径，发送本地图片，相对路径于plugins/MiraiAPIHTTP/images
 
 	XML     string `json:"xml,omitempty"`     //(Xml) xml消息本体
 	JSON    string `json:"json,omitempty"`    //(Json) json消息本体
 	Content string `json:"content,omitempty"` //(App) 不知道干嘛的，mirai也没有说明，估计是小程序连接？
+
+	VoiceID string `json:"voiceId,omitempty"` //(Voice)语音ID，发送时优先级比url高
+	Length  int64  `json:"length,omitempty"`  //(Voice)语音时长
+
+	Title    string        `json:"title,omitempty"`    //(Forward,MusicShare)标题
+	Brief    string        `json:"brief,omitempty"`    //(Forward,MusicShare)消息列表中显示的简介
+	Source   string        `json:"source,omitempty"`   //(Forward)来源
+	Summary  string        `json:"summary,omitempty"`  //(Forward,MusicShare)总结或概括
+	NodeList []ForwardNode `json:"nodeList,omitempty"` //(Forward)消息节点
+
+	FileID     string `json:"-"`                    //(File)文件ID，序列化为id字段
+	InternalID int64  `json:"internalId,omitempty"` //(File)腾讯内部文件ID
+	Size       int64  `json:"size,omitempty"`       //(File)文件大小
+
+	Kind       string `json:"kind,omitempty"`       //(MusicShare)类型，如 NeteaseCloudMusic QQMusic MiguMusic
+	JumpURL    string `json:"jumpUrl,omitempty"`    //(MusicShare)跳转路径
+	PictureURL string `json:"pictureUrl,omitempty"` //(MusicShare)封面路径
+	MusicURL   string `json:"musicUrl,omitempty"`   //(MusicShare)音源路径
+
+	Value int `json:"value,omitempty"` //(Dice)点数
+
+	Code string `json:"code,omitempty"` //(MiraiCode)Mirai码
+
+	Raw json.RawMessage `json:"-"` // 未知类型消息的原始数据，序列化时原样输出
+}
+
+// ForwardNode 合并转发消息节点
+type ForwardNode struct {
+	SenderID     int64     `json:"senderId,omitempty"`     // 发送者QQ号
+	Time         int64     `json:"time,omitempty"`         // 发送时间
+	SenderName   string    `json:"senderName,omitempty"`   // 显示名称
+	MessageChain []Message `json:"messageChain,omitempty"` // 消息链
+	MessageID    int64     `json:"messageId,omitempty"`    // 可仅使用消息id引用已有消息，优先级比messageChain低
+}
+
+// messageAlias 用于 Message 的自定义序列化
+type messageAlias Message
+
+// UnmarshalJSON 解析消息，File 的 id 为字符串，未知类型保留原始数据
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var v struct {
+		messageAlias
+		ID json.RawMessage `json:"id,omitempty"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Message(v.messageAlias)
+	if !knownTypes[m.Type] {
+		m.Raw = append(json.RawMessage(nil), data...)
+	}
+	if len(v.ID) == 0 || string(v.ID) == "null" {
+		return nil
+	}
+	if m.Type == MsgTypeFile {
+		return json.Unmarshal(v.ID, &m.FileID)
+	}
+	// 未知类型的 id 可能不是数字，原始数据已保留，忽略解析错误
+	if err := json.Unmarshal(v.ID, &m.ID); err != nil && knownTypes[m.Type] {
+		return err
+	}
+	return nil
+}
+
+// MarshalJSON 序列化消息，未知类型原样输出原始数据
+func (m Message) MarshalJSON() ([]byte, error) {
+	if m.Raw != nil && !knownTypes[m.Type] {
+		return m.Raw, nil
+	}
+	v := struct {
+		messageAlias
+		ID interface{} `json:"id,omitempty"`
+	}{messageAlias: messageAlias(m)}
+	if m.Type == MsgTypeFile {
+		if m.FileID != "" {
+			v.ID = m.FileID
+		}
+	} else if m.ID != 0 {
+		v.ID = m.ID
+	}
+	return json.Marshal(v)
 }
 
 // PlainMessage 文本消息
@@ -128,3 +233,50 @@ func RichMessage(t, content string) Message {
 func PokeMessage(name string) Message {
 	return Message{Type: MsgTypePoke, Name: name}
 }
+
+// VoiceMessage 语音消息
+// t 为 id url path 之一
+func VoiceMessage(t, v string) Message {
+	m := Message{Type: MsgTypeVoice}
+	switch t {
+	case "id":
+		m.VoiceID = v
+	case "url":
+		m.ImageURL = v
+	case "path":
+		m.ImagePath = v
+	default:
+		return Message{}
+	}
+	return m
+}
+
+// ForwardMessage 合并转发消息
+func ForwardMessage(nodes ...ForwardNode) Message {
+	return Message{Type: MsgTypeForward, NodeList: nodes}
+}
+
+// FileMessage 文件消息
+func FileMessage(id, name string, size int64) Message {
+	return Message{Type: MsgTypeFile, FileID: id, Name: name, Size: size}
+}
+
+// MusicShareMessage 音乐分享消息
+func MusicShareMessage(kind, title, summary, jumpURL, pictureURL, musicURL string) Message {
+	return Message{Type: MsgTypeMusicShare, Kind: kind, Title: title, Summary: summary, JumpURL: jumpURL, PictureURL: pictureURL, MusicURL: musicURL}
+}
+
+// DiceMessage 骰子消息
+func DiceMessage(value int) Message {
+	return Message{Type: MsgTypeDice, Value: value}
+}
+
+// MarketFaceMessage 商城表情消息
+func MarketFaceMessage(id int64, name string) Message {
+	return Message{Type: MsgTypeMarketFace, ID: id, Name: name}
+}
+
+// MiraiCodeMessage Mirai码消息
+func MiraiCodeMessage(code string) Message {
+	return Message{Type: MsgTypeMiraiCode, Code: code}
+}
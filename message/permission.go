@@ -11,3 +11,60 @@ const (
 	// MEMBER 普通成员
 	MEMBER
 )
+
+// 权限字符串
+const (
+	// PermissionOwner 群主
+	PermissionOwner = "OWNER"
+	// PermissionAdministrator 管理员
+	PermissionAdministrator = "ADMINISTRATOR"
+	// PermissionMember 普通成员
+	PermissionMember = "MEMBER"
+)
+
+// ParsePermission 将权限字符串转为 OWNER ADMINISTRATOR MEMBER 之一，无法识别时返回 0
+func ParsePermission(s string) int {
+	switch s {
+	case PermissionOwner:
+		return OWNER
+	case PermissionAdministrator:
+		return ADMINISTRATOR
+	case PermissionMember:
+		return MEMBER
+	}
+	return 0
+}
+
+// PermissionName 将 OWNER ADMINISTRATOR MEMBER 转为权限字符串
+func PermissionName(p int) string {
+	switch p {
+	case OWNER:
+		return PermissionOwner
+	case ADMINISTRATOR:
+		return PermissionAdministrator
+	case MEMBER:
+		return PermissionMember
+	}
+	return ""
+}
+
+// HasPermission 判断权限字符串 s 是否不低于 min（OWNER > ADMINISTRATOR > MEMBER）
+func HasPermission(s string, min int) bool {
+	p := ParsePermission(s)
+	return p != 0 && p <= min
+}
+
+// Role 发送者在群中的角色，非群消息时返回 0
+func (s Sender) Role() int {
+	return ParsePermission(s.Permission)
+}
+
+// Role 成员在群中的角色
+func (m Member) Role() int {
+	return ParsePermission(m.Permission)
+}
+
+// BotRole Bot在群中的角色
+func (g Group) BotRole() int {
+	return ParsePermission(g.Permisson)
+}
@@ -0,0 +1,140 @@
+package gomirai
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// Middleware 事件处理中间件
+// 通过 EventHandler.Use 添加，Guard.Wrap 亦可作为中间件使用
+type Middleware func(next Handler) Handler
+
+// Recover 捕获处理函数中的 panic 并记录日志
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(bot *Bot, e message.Event) {
+			defer func() {
+				if r := recover(); r != nil {
+					bot.Logger.Errorf("Handler Panic: %v Event: %s\n%s", r, e.EventType(), debug.Stack())
+				}
+			}()
+			next(bot, e)
+		}
+	}
+}
+
+// Logging 记录事件类型及处理耗时
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(bot *Bot, e message.Event) {
+			start := time.Now()
+			next(bot, e)
+			bot.Logger.Debugln("Handle", e.EventType(), "in", time.Since(start))
+		}
+	}
+}
+
+// IgnoreSelf 忽略Bot自身发送的消息
+func IgnoreSelf() Middleware {
+	return func(next Handler) Handler {
+		return func(bot *Bot, e message.Event) {
+			if c, ok := e.(message.ChatEvent); ok && c.Chat().Sender.ID == bot.QQ {
+				return
+			}
+			next(bot, e)
+		}
+	}
+}
+
+// Blacklist 忽略指定QQ号发送的消息
+func Blacklist(qq ...int64) Middleware {
+	return func(next Handler) Handler {
+		return func(bot *Bot, e message.Event) {
+			if c, ok := e.(message.ChatEvent); ok && containsID(qq, c.Chat().Sender.ID) {
+				return
+			}
+			next(bot, e)
+		}
+	}
+}
+
+// dedupKey 用于去重的消息标识
+type dedupKey struct {
+	Type   string
+	Group  int64
+	Sender int64
+	ID     int64
+}
+
+// Dedup 忽略 window 时间内重复收到的消息（依据消息id）
+func Dedup(window time.Duration) Middleware {
+	var mu sync.Mutex
+	seen := make(map[dedupKey]time.Time)
+	return func(next Handler) Handler {
+		return func(bot *Bot, e message.Event) {
+			c, ok := e.(message.ChatEvent)
+			if !ok {
+				next(bot, e)
+				return
+			}
+			m := c.Chat()
+			key := dedupKey{m.Type, m.Sender.Group.ID, m.Sender.ID, m.MessageChain.SourceID()}
+			now := time.Now()
+			mu.Lock()
+			for k, t := range seen {
+				if now.Sub(t) > window {
+					delete(seen, k)
+				}
+			}
+			_, dup := seen[key]
+			seen[key] = now
+			mu.Unlock()
+			if !dup {
+				next(bot, e)
+			}
+		}
+	}
+}
+
+// RateLimit 限制每个用户在 per 时间内最多触发 n 次处理，超出的消息将被忽略
+func RateLimit(n int, per time.Duration) Middleware {
+	type window struct {
+		start time.Time
+		count int
+	}
+	var mu sync.Mutex
+	users := make(map[int64]*window)
+	return func(next Handler) Handler {
+		return func(bot *Bot, e message.Event) {
+			c, ok := e.(message.ChatEvent)
+			if !ok {
+				next(bot, e)
+				return
+			}
+			qq := c.Chat().Sender.ID
+			now := time.Now()
+			mu.Lock()
+			w, ok := users[qq]
+			if !ok || now.Sub(w.start) >= per {
+				for k, v := range users {
+					if now.Sub(v.start) >= per {
+						delete(users, k)
+					}
+				}
+				w = &window{start: now}
+				users[qq] = w
+			}
+			w.count++
+			allow := w.count <= n
+			mu.Unlock()
+			if !allow {
+				bot.Logger.Debugln("Rate Limited", qq)
+				return
+			}
+			next(bot, e)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package gomirai
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/virzz/gomirai/message"
+)
+
+// 自动管理的处理原因
+const (
+	// ModerationFlood 发言过快
+	ModerationFlood = "flood"
+	// ModerationRepeat 重复发言
+	ModerationRepeat = "repeat"
+	// ModerationKeyword 触发违禁词
+	ModerationKeyword = "keyword"
+)
+
+// ModerationConfig 群自动管理配置，为 0 或为空的项不启用
+type ModerationConfig struct {
+	// RateLimit 每个成员在 RatePer 时间内最多发言条数
+	RateLimit int
+	RatePer   time.Duration
+	// RepeatLimit 同一成员连续发送相同内容的最多条数
+	RepeatLimit int
+	// Keywords 违禁词，消息文本包含其一时撤回
+	Keywords []string
+	// Patterns 违禁正则表达式，消息文本匹配其一时撤回
+	Patterns []string
+	// MuteSteps 第 n 次违规的禁言时长，超出时使用最后一项
+	MuteSteps []time.Duration
+	// KickAfter 违规达到该次数时踢出
+	KickAfter int
+	// KickMessage 踢出时附带的消息
+	KickMessage string
+	// ResetAfter 距上次违规超过该时间后违规次数清零
+	ResetAfter time.Duration
+	// Exempt 不受管理的成员，群主及管理员总是不受管理
+	Exempt   []int64
+	patterns []*regexp.Regexp
+}
+
+// compile 编译正则表达式
+func (c *ModerationConfig) compile() error {
+	c.patterns = c.patterns[:0]
+	for _, p := range c.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		c.patterns = append(c.patterns, re)
+	}
+	return nil
+}
+
+// keyword 返回消息文本触发的违禁词，未触发时返回空
+func (c *ModerationConfig) keyword(text string) string {
+	for _, k := range c.Keywords {
+		if k != "" && strings.Contains(text, k) {
+			return k
+		}
+	}
+	for _, re := range c.patterns {
+		if re.MatchString(text) {
+			return re.String()
+		}
+	}
+	return ""
+}
+
+// ModerationAction 自动管理执行的操作
+type ModerationAction struct {
+	Time   time.Time
+	Group  int64
+	User   int64
+	Reason string
+	// Action 为 "recall" "mute" "kick" 之一
+	Action string
+	// Duration 禁言时长
+	Duration time.Duration
+	// Violations 该成员的违规次数
+	Violations int
+	// MessageID 触发的消息id
+	MessageID int64
+	// Text 触发的消息文本
+	Text string
+	Err  error
+}
+
+// memberState 成员的发言记录
+type memberState struct {
+	seen       time.Time
+	recent     []time.Time
+	lastText   string
+	repeat     int
+	violations int
+	violatedAt time.Time
+}
+
+// Moderator 群自动管理：刷屏、重复发言、违禁词检测及逐级禁言
+// 通过 SetGroup 为各群设置配置，未设置的群使用 SetDefault 设置的配置，均未设置时不管理
+type Moderator struct {
+	// OnAction 每次执行操作后调用
+	OnAction func(bot *Bot, a ModerationAction)
+	// Auditor 不为 nil 时禁言及踢出通过其执行并写入审计记录，操作者为 Bot
+	Auditor *Auditor
+	mu      sync.Mutex
+	groups  map[int64]*ModerationConfig
+	def     *ModerationConfig
+	members map[ConversationKey]*memberState
+	pruned  time.Time
+}
+
+// NewModerator 新建群自动管理
+func NewModerator() *Moderator {
+	return &Moderator{
+		groups:  make(map[int64]*ModerationConfig),
+		members: make(map[ConversationKey]*memberState),
+	}
+}
+
+// SetDefault 设置默认配置
+func (m *Moderator) SetDefault(c *ModerationConfig) error {
+	if err := c.compile(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.def = c
+	m.mu.Unlock()
+	return nil
+}
+
+// SetGroup 设置群的配置，c 为 nil 时使用默认配置
+func (m *Moderator) SetGroup(group int64, c *ModerationConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c == nil {
+		delete(m.groups, group)
+		return nil
+	}
+	if err := c.compile(); err != nil {
+		return err
+	}
+	m.groups[group] = c
+	return nil
+}
+
+// Attach 将自动管理注册至 EventHandler
+func (m *Moderator) Attach(h *EventHandler) {
+	h.OnEvent(message.EventReceiveGroupMessage, func(bot *Bot, e message.Event) {
+		m.Check(NewMessageContext(bot, e.(message.ChatEvent)))
+	})
+}
+
+// config 群使用的配置
+func (m *Moderator) config(group int64) *ModerationConfig {
+	if c, ok := m.groups[group]; ok {
+		return c
+	}
+	return m.def
+}
+
+// Check 检查群消息，违规时执行相应操作，返回是否违规
+func (m *Moderator) Check(mc *MessageContext) bool {
+	s := mc.Sender()
+	if s.Group.ID == 0 || s.Role() == message.OWNER || s.Role() == message.ADMINISTRATOR {
+		return false
+	}
+	// 已知 Bot 为普通成员时无法进行管理；角色未知时仍尝试执行，失败将通过 OnAction 及日志反馈
+	if s.Group.BotRole() == message.MEMBER {
+		return false
+	}
+	text := mc.Chain().PlainText()
+	now := time.Now()
+
+	m.mu.Lock()
+	c := m.config(s.Group.ID)
+	if c == nil || containsID(c.Exempt, s.ID) {
+		m.mu.Unlock()
+		return false
+	}
+	m.prune(now)
+	key := KeyOf(mc.Event)
+	st, ok := m.members[key]
+	if !ok {
+		st = &memberState{}
+		m.members[key] = st
+	}
+	st.seen = now
+
+	reason, detail := "", ""
+	if k := c.keyword(text); k != "" {
+		reason, detail = ModerationKeyword, k
+	}
+	if c.RateLimit > 0 && c.RatePer > 0 {
+		i := 0
+		for i < len(st.recent) && now.Sub(st.recent[i]) >= c.RatePer {
+			i++
+		}
+		st.recent = append(st.recent[i:], now)
+		if reason == "" && len(st.recent) > c.RateLimit {
+			reason = ModerationFlood
+			st.recent = st.recent[:0]
+		}
+	}
+	if text != "" && text == st.lastText {
+		st.repeat++
+	} else {
+		st.lastText, st.repeat = text, 1
+	}
+	if reason == "" && c.RepeatLimit > 0 && st.repeat > c.RepeatLimit {
+		reason = ModerationRepeat
+		st.repeat = 0
+	}
+	if reason == "" {
+		m.mu.Unlock()
+		return false
+	}
+	if c.ResetAfter > 0 && now.Sub(st.violatedAt) > c.ResetAfter {
+		st.violations = 0
+	}
+	st.violations++
+	st.violatedAt = now
+	violations := st.violations
+	m.mu.Unlock()
+
+	base := ModerationAction{
+		Group:      s.Group.ID,
+		User:       s.ID,
+		Reason:     reason,
+		Violations: violations,
+		MessageID:  mc.Chain().SourceID(),
+		Text:       text,
+	}
+	if detail != "" {
+		base.Reason += ":" + detail
+	}
+	m.punish(mc, c, base)
+	return true
+}
+
+// punish 撤回触发的消息并逐级禁言或踢出
+func (m *Moderator) punish(mc *MessageContext, c *ModerationConfig, a ModerationAction) {
+	a.Action = "recall"
+	m.record(mc.Bot, a, mc.Recall())
+
+	if c.KickAfter > 0 && a.Violations >= c.KickAfter {
+		a.Action = "kick"
+		var err error
+		if m.Auditor != nil {
+			err = m.Auditor.KickContext(mc.Ctx, mc.Bot.QQ, a.Group, a.User, c.KickMessage, a.Reason)
+		} else {
+			err = mc.Bot.KickContext(mc.Ctx, a.Group, a.User, c.KickMessage)
+		}
+		m.record(mc.Bot, a, err)
+		m.mu.Lock()
+		delete(m.members, ConversationKey{Group: a.Group, User: a.User})
+		m.mu.Unlock()
+		return
+	}
+	if len(c.MuteSteps) == 0 {
+		return
+	}
+	step := a.Violations - 1
+	if step >= len(c.MuteSteps) {
+		step = len(c.MuteSteps) - 1
+	}
+	a.Action, a.Duration = "mute", c.MuteSteps[step]
+	if a.Duration <= 0 {
+		return
+	}
+	if m.Auditor != nil {
+		m.record(mc.Bot, a, m.Auditor.MuteContext(mc.Ctx, mc.Bot.QQ, a.Group, a.User, a.Duration, a.Reason))
+	} else {
+		m.record(mc.Bot, a, mc.MuteSender(a.Duration))
+	}
+}
+
+// record 记录执行的操作
+func (m *Moderator) record(bot *Bot, a ModerationAction, err error) {
+	a.Time, a.Err = time.Now(), err
+	log := bot.Logger.WithFields(logrus.Fields{
+		"group":      a.Group,
+		"member":     a.User,
+		"reason":     a.Reason,
+		"violations": a.Violations,
+		"messageId":  a.MessageID,
+		"text":       a.Text,
+	})
+	if a.Duration > 0 {
+		log = log.WithField("duration", a.Duration)
+	}
+	if err != nil {
+		log.Errorln("Moderation", a.Action, "Failed:", err)
+	} else {
+		log.Infoln("Moderation", a.Action)
+	}
+	if m.OnAction != nil {
+		m.OnAction(bot, a)
+	}
+}
+
+// prune 每分钟至多一次，清除一小时未发言且违规记录已过期的成员，调用时需持有锁
+func (m *Moderator) prune(now time.Time) {
+	if now.Sub(m.pruned) < time.Minute {
+		return
+	}
+	m.pruned = now
+	for k, st := range m.members {
+		if now.Sub(st.seen) > time.Hour && (st.violations == 0 || now.Sub(st.violatedAt) > 24*time.Hour) {
+			delete(m.members, k)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package gomirai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/virzz/gomirai/message"
+)
+
+// 申请事件的处理动作
+const (
+	// PolicyAgree 同意
+	PolicyAgree = "agree"
+	// PolicyRefuse 拒绝
+	PolicyRefuse = "refuse"
+	// PolicyIgnore 忽略，好友申请及邀请无忽略操作，此时不作响应
+	PolicyIgnore = "ignore"
+	// PolicyBan 拒绝并添加黑名单，邀请无黑名单操作，此时仅拒绝
+	PolicyBan = "ban"
+	// PolicyPass 不作处理，交由后续处理函数
+	PolicyPass = ""
+)
+
+// PolicyRule 申请事件处理规则，为空的条件不作限制
+type PolicyRule struct {
+	// Name 规则名称，记录于审计日志
+	Name string `json:"name"`
+	// Types 事件类型，为 message.EventMemberJoinRequest 等申请事件类型
+	Types []string `json:"types"`
+	// Groups 申请的群号（好友申请为申请人所在群）
+	Groups []int64 `json:"groups"`
+	// Users 申请人或邀请人QQ号
+	Users []int64 `json:"users"`
+	// Pattern 申请消息需匹配的正则表达式
+	Pattern string `json:"pattern"`
+	// Action 满足条件时的动作，为 Policy* 常量之一
+	Action string `json:"action"`
+	// Message 拒绝时回复的消息
+	Message string `json:"message"`
+	re      *regexp.Regexp
+}
+
+// PolicyConfig 规则文件内容
+// 依次检查黑名单、信任列表及各条规则，均不满足时使用 Default
+type PolicyConfig struct {
+	// Blacklist 黑名单，申请人在其中时执行 ban
+	Blacklist []int64 `json:"blacklist"`
+	// Trusted 信任的用户，申请人或邀请人在其中时执行 agree
+	Trusted []int64 `json:"trusted"`
+	// Rules 按顺序匹配的规则，第一条满足的规则生效
+	Rules []*PolicyRule `json:"rules"`
+	// Default 均不满足时的动作，默认为 PolicyPass
+	Default string `json:"default"`
+	// RefuseMessage 未指定消息时拒绝回复的消息
+	RefuseMessage string `json:"refuseMessage"`
+}
+
+// PolicyEngine 基于规则文件的申请事件处理
+// 规则文件为 JSON 格式，通过 Watch 在文件修改后自动重新加载
+type PolicyEngine struct {
+	// Logger 用于 Watch 输出重新加载的结果，默认使用 logrus 的标准 Logger
+	Logger *logrus.Entry
+	// Store 不为 nil 时每次处理均写入审计记录，操作类型为 AuditRequest
+	Store AuditStore
+	// OnDecision 每次处理后调用
+	OnDecision func(bot *Bot, e AuditEntry)
+	path       string
+	mu         sync.RWMutex
+	config     *PolicyConfig
+	modTime    time.Time
+}
+
+// NewPolicyEngine 从规则文件新建处理引擎
+func NewPolicyEngine(path string) (*PolicyEngine, error) {
+	p := &PolicyEngine{path: path, Logger: logrus.NewEntry(logrus.StandardLogger())}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload 重新加载规则文件，加载失败时保留原有规则
+func (p *PolicyEngine) Reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	config := &PolicyConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return err
+	}
+	if err := config.compile(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.config = config
+	p.modTime = info.ModTime()
+	p.mu.Unlock()
+	return nil
+}
+
+// compile 检查规则并编译正则表达式
+func (c *PolicyConfig) compile() error {
+	if !validAction(c.Default) {
+		return errors.New("无效的动作: " + c.Default)
+	}
+	for _, r := range c.Rules {
+		if !validAction(r.Action) {
+			return errors.New("规则 " + r.Name + " 无效的动作: " + r.Action)
+		}
+		if r.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return err
+		}
+		r.re = re
+	}
+	return nil
+}
+
+func validAction(action string) bool {
+	switch action {
+	case PolicyAgree, PolicyRefuse, PolicyIgnore, PolicyBan, PolicyPass:
+		return true
+	}
+	return false
+}
+
+// Watch 每隔 interval 检查规则文件，修改后重新加载，直至 ctx 取消
+func (p *PolicyEngine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(p.path)
+		if err != nil {
+			continue
+		}
+		p.mu.RLock()
+		changed := !info.ModTime().Equal(p.modTime)
+		p.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := p.Reload(); err != nil {
+			p.Logger.Errorln("Reload Policy Failed:", err)
+			continue
+		}
+		p.Logger.Infoln("Policy Reloaded:", p.path)
+	}
+}
+
+// Attach 将处理引擎注册至 EventHandler
+func (p *PolicyEngine) Attach(h *EventHandler) {
+	h.OnEvent(message.EventMemberJoinRequest, p.Handle)
+	h.OnEvent(message.EventNewFriendRequest, p.Handle)
+	h.OnEvent(message.EventBotInvitedJoinGroupRequest, p.Handle)
+}
+
+// Evaluate 计算申请事件对应的动作及生效的规则名称
+func (p *PolicyEngine) Evaluate(e message.Event) (action, rule, reply string) {
+	req := requestOf(e)
+	if req == nil {
+		return PolicyPass, "", ""
+	}
+	p.mu.RLock()
+	c := p.config
+	p.mu.RUnlock()
+
+	reply = c.RefuseMessage
+	switch {
+	case containsID(c.Blacklist, req.FromID):
+		return PolicyBan, "blacklist", reply
+	case containsID(c.Trusted, req.FromID):
+		return PolicyAgree, "trusted", ""
+	}
+	for _, r := range c.Rules {
+		if !r.match(e.EventType(), req) {
+			continue
+		}
+		if r.Message != "" {
+			reply = r.Message
+		}
+		return r.Action, r.Name, reply
+	}
+	return c.Default, "default", reply
+}
+
+func (r *PolicyRule) match(t string, req *message.RequestEvent) bool {
+	if len(r.Types) > 0 {
+		ok := false
+		for _, v := range r.Types {
+			if v == t {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.Groups) > 0 && !containsID(r.Groups, req.GroupID) {
+		return false
+	}
+	if len(r.Users) > 0 && !containsID(r.Users, req.FromID) {
+		return false
+	}
+	return r.re == nil || r.re.MatchString(req.Message)
+}
+
+// Handle 按规则处理申请事件，可直接作为 Handler 使用
+func (p *PolicyEngine) Handle(bot *Bot, e message.Event) {
+	req := requestOf(e)
+	if req == nil {
+		return
+	}
+	action, rule, reply := p.Evaluate(e)
+	if action == PolicyPass {
+		return
+	}
+	err := respondPolicy(bot, e, action, reply)
+	entry := AuditEntry{
+		Time:     time.Now(),
+		Operator: bot.QQ,
+		Action:   AuditRequest,
+		Group:    req.GroupID,
+		Target:   req.FromID,
+		Reason:   rule,
+		Detail:   fmt.Sprintf("type=%s eventId=%d action=%s message=%q", e.EventType(), req.EventID, action, req.Message),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		bot.Logger.Errorln("Policy Respond Failed:", err)
+	}
+	if p.Store != nil {
+		if werr := p.Store.Append(entry); werr != nil {
+			bot.Logger.Errorln("Write Audit Failed:", werr)
+		}
+	}
+	if p.OnDecision != nil {
+		p.OnDecision(bot, entry)
+	}
+}
+
+// respondPolicy 以动作对应的操作响应申请事件
+func respondPolicy(bot *Bot, e message.Event, action, reply string) error {
+	switch e := e.(type) {
+	case *message.MemberJoinRequestEvent:
+		op := OperateAgree
+		switch action {
+		case PolicyRefuse:
+			op = OperateRefuse
+		case PolicyIgnore:
+			op = OperateIgnore
+		case PolicyBan:
+			op = OperateRefuseBan
+		}
+		if op == OperateAgree {
+			reply = ""
+		}
+		return bot.RespondMemberJoinRequest(e.EventID, e.FromID, e.GroupID, op, reply)
+	case *message.NewFriendRequestEvent:
+		op := FriendAgree
+		switch action {
+		case PolicyIgnore:
+			return nil
+		case PolicyRefuse:
+			op = FriendRefuse
+		case PolicyBan:
+			op = FriendRefuseBan
+		}
+		if op == FriendAgree {
+			reply = ""
+		}
+		return bot.RespondNewFriendRequest(e.EventID, e.FromID, e.GroupID, op, reply)
+	case *message.BotInvitedJoinGroupRequestEvent:
+		op := InviteAgree
+		switch action {
+		case PolicyIgnore:
+			return nil
+		case PolicyRefuse, PolicyBan:
+			op = InviteRefuse
+		}
+		if op == InviteAgree {
+			reply = ""
+		}
+		return bot.RespondBotInvitedJoinGroupRequest(e.EventID, e.FromID, e.GroupID, op, reply)
+	}
+	return nil
+}
+
+// requestOf 取出申请事件的公共部分，非申请事件返回 nil
+func requestOf(e message.Event) *message.RequestEvent {
+	switch e := e.(type) {
+	case *message.MemberJoinRequestEvent:
+		return &e.RequestEvent
+	case *message.NewFriendRequestEvent:
+		return &e.RequestEvent
+	case *message.BotInvitedJoinGroupRequestEvent:
+		return &e.RequestEvent
+	}
+	return nil
+}
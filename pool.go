@@ -0,0 +1,145 @@
+package gomirai
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// poolTask 待处理的事件
+type poolTask struct {
+	bot *Bot
+	e   message.Event
+	h   Handler
+}
+
+// WorkerPool 按会话分配的并发处理池
+// 同一群（或同一好友）的事件总是由同一 worker 按顺序处理，不同会话的事件并发处理
+type WorkerPool struct {
+	queues    []chan poolTask
+	wg        sync.WaitGroup
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+}
+
+// NewWorkerPool 新建处理池
+// workers 为最大并发数，queueSize 为每个 worker 的队列长度，队列满时 Submit 阻塞
+func NewWorkerPool(workers, queueSize int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &WorkerPool{queues: make([]chan poolTask, workers)}
+	for i := range p.queues {
+		p.queues[i] = make(chan poolTask, queueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *WorkerPool) work(queue chan poolTask) {
+	defer p.wg.Done()
+	for t := range queue {
+		t.h(t.bot, t.e)
+	}
+}
+
+// Submit 提交事件，返回 false 表示处理池已关闭
+func (p *WorkerPool) Submit(bot *Bot, e message.Event, h Handler) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+	h32 := fnv.New32a()
+	h32.Write([]byte(OrderKey(e)))
+	p.queues[h32.Sum32()%uint32(len(p.queues))] <- poolTask{bot: bot, e: e, h: h}
+	return true
+}
+
+// Close 停止接收新事件，并等待已提交的事件处理完毕
+func (p *WorkerPool) Close() {
+	p.closeOnce.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		for _, q := range p.queues {
+			close(q)
+		}
+		p.mu.Unlock()
+	})
+	p.wg.Wait()
+}
+
+// OrderKey 事件的顺序标识，标识相同的事件按顺序处理
+// 群相关事件为群号，好友消息为好友QQ号，其余事件为空
+func OrderKey(e message.Event) string {
+	if c, ok := e.(message.ChatEvent); ok {
+		s := c.Chat().Sender
+		if s.Group.ID != 0 {
+			return "g" + strconv.FormatInt(s.Group.ID, 10)
+		}
+		return "f" + strconv.FormatInt(s.ID, 10)
+	}
+	if group := EventGroup(e); group != 0 {
+		return "g" + strconv.FormatInt(group, 10)
+	}
+	return ""
+}
+
+// EventGroup 事件相关的群号，与群无关的事件返回 0
+func EventGroup(e message.Event) int64 {
+	switch e := e.(type) {
+	case message.ChatEvent:
+		return e.Chat().Sender.Group.ID
+	case *message.BotGroupPermissionChangeEvent:
+		return e.Group.ID
+	case *message.BotMuteEvent:
+		return e.Operator.Group.ID
+	case *message.BotUnmuteEvent:
+		return e.Operator.Group.ID
+	case *message.BotJoinGroupEvent:
+		return e.Group.ID
+	case *message.BotLeaveEventActive:
+		return e.Group.ID
+	case *message.BotLeaveEventKick:
+		return e.Group.ID
+	case *message.GroupRecallEvent:
+		return e.Group.ID
+	case *message.GroupNameChangeEvent:
+		return e.Group.ID
+	case *message.GroupEntranceAnnouncementChangeEvent:
+		return e.Group.ID
+	case *message.GroupMuteAllEvent:
+		return e.Group.ID
+	case *message.GroupAllowAnonymousChatEvent:
+		return e.Group.ID
+	case *message.GroupAllowConfessTalkEvent:
+		return e.Group.ID
+	case *message.GroupAllowMemberInviteEvent:
+		return e.Group.ID
+	case *message.MemberJoinEvent:
+		return e.Member.Group.ID
+	case *message.MemberLeaveEventKick:
+		return e.Member.Group.ID
+	case *message.MemberLeaveEventQuit:
+		return e.Member.Group.ID
+	case *message.MemberCardChangeEvent:
+		return e.Member.Group.ID
+	case *message.MemberSpecialTitleChangeEvent:
+		return e.Member.Group.ID
+	case *message.MemberPermissionChangeEvent:
+		return e.Member.Group.ID
+	case *message.MemberMuteEvent:
+		return e.Member.Group.ID
+	case *message.MemberUnmuteEvent:
+		return e.Member.Group.ID
+	case *message.MemberJoinRequestEvent:
+		return e.GroupID
+	case *message.BotInvitedJoinGroupRequestEvent:
+		return e.GroupID
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package gomirai
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Mirai-api-http 协议版本
+const (
+	// ProtocolAuto 首次认证时通过 About 自动检测
+	ProtocolAuto = iota
+	// ProtocolV1 mirai-api-http 1.x
+	ProtocolV1
+	// ProtocolV2 mirai-api-http 2.x
+	ProtocolV2
+)
+
+// v2ListPaths 2.x 中将结果放入 data 字段的接口
+var v2ListPaths = map[string]bool{
+	"/friendList":   true,
+	"/groupList":    true,
+	"/memberList":   true,
+	"/countMessage": true,
+}
+
+// v2TargetKeys 2.x 中将目标统一为 target 字段的接口及 1.x 中对应的字段
+var v2TargetKeys = map[string]string{
+	"/sendFriendMessage": "qq",
+	"/sendGroupMessage":  "group",
+}
+
+// DetectProtocol 通过 About 获取插件版本并设置 Protocol
+func (c *Client) DetectProtocol(ctx context.Context) (int, error) {
+	c.protoMu.Lock()
+	defer c.protoMu.Unlock()
+	return c.detectProtocol(ctx)
+}
+
+// detectProtocol 同 DetectProtocol，调用时需持有 protoMu
+func (c *Client) detectProtocol(ctx context.Context) (int, error) {
+	version, err := c.AboutContext(ctx)
+	if err != nil {
+		return ProtocolAuto, err
+	}
+	c.Protocol = ProtocolV1
+	if strings.HasPrefix(strings.TrimPrefix(version, "v"), "2.") {
+		c.Protocol = ProtocolV2
+	}
+	c.Logger.Infoln("Mirai-api-http Version:", version)
+	return c.Protocol, nil
+}
+
+// protocol 返回使用的协议版本，未设置时自动检测，检测失败时使用 1.x
+// 多个 Bot 同时认证时仅检测一次
+func (c *Client) protocol(ctx context.Context) int {
+	c.protoMu.Lock()
+	defer c.protoMu.Unlock()
+	if c.Protocol == ProtocolAuto {
+		if _, err := c.detectProtocol(ctx); err != nil {
+			c.Logger.Warnln("Detect Protocol Failed, Fallback to 1.x:", err)
+			c.Protocol = ProtocolV1
+		}
+	}
+	return c.Protocol
+}
+
+// version 返回当前的协议版本，不进行检测
+func (c *Client) version() int {
+	c.protoMu.Lock()
+	defer c.protoMu.Unlock()
+	return c.Protocol
+}
+
+// adaptRequest 将 1.x 格式的请求参数转换为当前协议的格式
+func (c *Client) adaptRequest(path string, data map[string]interface{}) {
+	if c.version() != ProtocolV2 {
+		return
+	}
+	if key, ok := v2TargetKeys["/"+strings.TrimPrefix(path, "/")]; ok {
+		if v, ok := data[key]; ok {
+			data["target"] = v
+			delete(data, key)
+		}
+	}
+}
+
+// adaptResponse 将当前协议的响应转换为 1.x 的格式
+func (c *Client) adaptResponse(path string, res string) string {
+	if c.version() != ProtocolV2 || !v2ListPaths["/"+strings.TrimPrefix(path, "/")] {
+		return res
+	}
+	if data := gjson.Get(res, "data"); data.Exists() {
+		return data.Raw
+	}
+	return res
+}
+
+// adaptFrame 将 2.x websocket 消息中的 data 取出，1.x 消息原样返回
+func adaptFrame(frame gjson.Result) gjson.Result {
+	if frame.Get("syncId").Exists() {
+		return frame.Get("data")
+	}
+	return frame
+}
@@ -0,0 +1,78 @@
+package gomirai
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// MessageContext 绑定了 Bot 的消息事件
+// 根据消息来源（群、好友、临时会话）选择对应的接口进行回复等操作
+type MessageContext struct {
+	Bot   *Bot
+	Event *message.MessageEvent
+	// Ctx 所有请求使用的 context，默认为 Bot.Context()
+	Ctx context.Context
+}
+
+// NewMessageContext 新建 MessageContext，Ctx 为 Bot 运行时的 ctx
+func NewMessageContext(b *Bot, e message.ChatEvent) *MessageContext {
+	return &MessageContext{Bot: b, Event: e.Chat(), Ctx: b.Context()}
+}
+
+// Chain 收到的消息链
+func (c *MessageContext) Chain() message.Chain {
+	return message.GenChain(c.Event.MessageChain...)
+}
+
+// Sender 消息发送者
+func (c *MessageContext) Sender() message.Sender {
+	return c.Event.Sender
+}
+
+// IsGroup 是否为群消息
+func (c *MessageContext) IsGroup() bool {
+	return c.Event.Type == message.EventReceiveGroupMessage
+}
+
+// Reply 回复消息，chain.Quote 不为 0 时引用回复
+// 临时会话不支持引用回复
+func (c *MessageContext) Reply(chain message.Chain) (int64, error) {
+	s := c.Event.Sender
+	switch c.Event.Type {
+	case message.EventReceiveGroupMessage:
+		return c.Bot.SendGroupMessageContext(c.Ctx, s.Group.ID, chain.Quote, chain.Msg...)
+	case message.EventReceiveFriendMessage:
+		return c.Bot.SendFriendMessageContext(c.Ctx, s.ID, chain.Quote, chain.Msg...)
+	case message.EventReceiveTempMessage:
+		return c.Bot.SendTempMessageContext(c.Ctx, s.Group.ID, s.ID, chain.Msg...)
+	}
+	return 0, errors.New("未知的消息类型: " + c.Event.Type)
+}
+
+// ReplyText 回复文本消息
+func (c *MessageContext) ReplyText(text string) (int64, error) {
+	return c.Reply(message.GenChain(message.PlainMessage(text)))
+}
+
+// ReplyQuoted 引用收到的消息进行回复
+func (c *MessageContext) ReplyQuoted(chain message.Chain) (int64, error) {
+	chain.Quote = c.Event.MessageChain.SourceID()
+	return c.Reply(chain)
+}
+
+// Recall 撤回收到的消息（撤回群员消息需要有相应权限）
+func (c *MessageContext) Recall() error {
+	return c.Bot.RecallContext(c.Ctx, c.Event.MessageChain.SourceID())
+}
+
+// MuteSender 禁言消息发送者，仅支持群消息及临时会话（需要有相关权限）
+func (c *MessageContext) MuteSender(d time.Duration) error {
+	s := c.Event.Sender
+	if s.Group.ID == 0 {
+		return errors.New("非群消息，无法禁言")
+	}
+	return c.Bot.MuteContext(c.Ctx, s.Group.ID, s.ID, int64(d/time.Second))
+}
@@ -0,0 +1,111 @@
+package gomirai
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/tidwall/gjson"
+)
+
+// session 返回当前的 SessionKey
+func (b *Bot) session() string {
+	b.sessionMu.RLock()
+	defer b.sessionMu.RUnlock()
+	return b.SessionKey
+}
+
+// RecoverSession 重新进行 Auth 与 Verify，并替换 Bot 的 SessionKey
+// failed 为失效的 SessionKey，若期间 SessionKey 已被替换则直接返回
+// 同时只进行一次恢复，期间其他请求仍使用原有的 SessionKey
+// 恢复结果会通过 Client.OnSessionRecover 通知，回调中可正常调用 Bot 的方法
+func (b *Bot) RecoverSession(ctx context.Context, failed string) error {
+	b.recoverMu.Lock()
+	if b.session() != failed {
+		b.recoverMu.Unlock()
+		return nil
+	}
+	b.Logger.Warnln("Session Invalid, Recovering")
+	key, err := b.Client.AuthContext(ctx)
+	if err == nil {
+		err = b.Client.verify(ctx, b.QQ, key)
+	}
+	if err != nil {
+		b.Logger.Errorln("Session Recover Failed:", err)
+	} else {
+		b.sessionMu.Lock()
+		b.SessionKey = key
+		b.sessionMu.Unlock()
+		b.Logger.Warnln("Session Recovered")
+	}
+	b.recoverMu.Unlock()
+
+	if b.Client.OnSessionRecover != nil {
+		b.Client.OnSessionRecover(b, failed, err)
+	}
+	return err
+}
+
+// isSessionError 判断是否为 Session 失效或未认证
+func isSessionError(err error) bool {
+	return errors.Is(err, ErrSessionInvalid) || errors.Is(err, ErrSessionNotVerified)
+}
+
+// withSession 使用当前 SessionKey 执行请求，Session 失效时恢复后重试一次
+func (b *Bot) withSession(ctx context.Context, do func(key string) (string, error)) (string, error) {
+	key := b.session()
+	res, err := do(key)
+	if !isSessionError(err) {
+		return res, err
+	}
+	if rerr := b.RecoverSession(ctx, key); rerr != nil {
+		return res, err
+	}
+	return do(b.session())
+}
+
+func (b *Bot) post(ctx context.Context, path string, data map[string]interface{}) (string, error) {
+	b.Client.adaptRequest(path, data)
+	return b.withSession(ctx, func(key string) (string, error) {
+		data["sessionKey"] = key
+		return b.Client.doPost(ctx, path, data)
+	})
+}
+
+func (b *Bot) postForm(ctx context.Context, path string, fields map[string]interface{}) (string, error) {
+	return b.withSession(ctx, func(key string) (string, error) {
+		fields["sessionKey"] = key
+		for _, v := range fields {
+			if seeker, ok := v.(io.Seeker); ok {
+				if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+					return "", err
+				}
+			}
+		}
+		return b.Client.doPostWithFormData(ctx, path, fields)
+	})
+}
+
+func (b *Bot) get(ctx context.Context, path string, params map[string]string) (string, error) {
+	if params == nil {
+		params = make(map[string]string)
+	}
+	res, err := b.withSession(ctx, func(key string) (string, error) {
+		params["sessionKey"] = key
+		return b.Client.doGet(ctx, path, params)
+	})
+	return b.Client.adaptResponse(path, res), err
+}
+
+// checkSession 检查 websocket 返回的数据是否表示 Session 失效，失效时进行恢复
+func (b *Bot) checkSession(ctx context.Context, key string, data gjson.Result) bool {
+	if data.Get("type").Exists() {
+		return false
+	}
+	switch data.Get("code").Int() {
+	case ErrSessionInvalid.Code, ErrSessionNotVerified.Code:
+		b.RecoverSession(ctx, key)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package gomirai
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// maxWebhookBody 上报请求体的最大长度
+const maxWebhookBody = 8 << 20
+
+// QuickReply 上报的快速响应，作为上报请求的响应体返回给 Mirai-api-http
+type QuickReply struct {
+	// Command 指令，如 sendGroupMessage
+	Command string `json:"command"`
+	// Content 指令参数
+	Content map[string]interface{} `json:"content"`
+}
+
+// ReplyTo 根据消息事件生成快速回复，chain.Quote 不为 0 时引用回复
+func ReplyTo(e message.ChatEvent, chain message.Chain) *QuickReply {
+	m := e.Chat()
+	content := map[string]interface{}{"messageChain": chain.Msg}
+	if chain.Quote != 0 {
+		content["quote"] = chain.Quote
+	}
+	r := &QuickReply{Content: content}
+	switch m.Type {
+	case message.EventReceiveGroupMessage:
+		r.Command = "sendGroupMessage"
+		content["target"] = m.Sender.Group.ID
+		content["group"] = m.Sender.Group.ID
+	case message.EventReceiveTempMessage:
+		r.Command = "sendTempMessage"
+		content["qq"] = m.Sender.ID
+		content["group"] = m.Sender.Group.ID
+	default:
+		r.Command = "sendFriendMessage"
+		content["target"] = m.Sender.ID
+		content["qq"] = m.Sender.ID
+	}
+	return r
+}
+
+// webhookConfig 上报相关设置
+type webhookConfig struct {
+	enabled    bool
+	header     string
+	token      string
+	quickReply func(e message.Event) *QuickReply
+}
+
+// SetWebhookAuth 校验上报请求的请求头，需在 Mirai-api-http 的 extraHeaders 中配置相同的值
+func (b *Bot) SetWebhookAuth(header, value string) {
+	b.webhook.header = header
+	b.webhook.token = value
+}
+
+// SetQuickReply 设置上报的快速响应，f 返回 nil 时不响应
+// f 在 http 请求中同步调用，事件随后仍会交由订阅者及 Chan 处理
+func (b *Bot) SetQuickReply(f func(e message.Event) *QuickReply) {
+	b.webhook.quickReply = f
+}
+
+// WebhookHandler 接收 Mirai-api-http 上报事件的 http.Handler
+// 调用后 Bot 使用上报模式，Listen 与 Run 不再主动获取事件
+// 收到的事件与轮询、websocket 方式相同，经过 Subscribe、WaitNext 后放入 Chan
+func (b *Bot) WebhookHandler() http.Handler {
+	b.webhook.enabled = true
+	return http.HandlerFunc(b.serveWebhook)
+}
+
+func (b *Bot) serveWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if b.webhook.header != "" && r.Header.Get(b.webhook.header) != b.webhook.token {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if qq := r.Header.Get("qq"); qq != "" && qq != strconv.FormatInt(b.QQ, 10) {
+		http.Error(w, "bot mismatch", http.StatusBadRequest)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	e, err := message.DecodeEvent(data)
+	if err != nil {
+		b.Logger.Errorln("Unmarshal Webhook Event", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var reply *QuickReply
+	if b.webhook.quickReply != nil {
+		reply = b.webhook.quickReply(e)
+	}
+	b.receive(e)
+	if reply == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	if err := json.NewEncoder(w).Encode(reply); err != nil {
+		b.Logger.Errorln("Write Quick Reply", err)
+	}
+}
@@ -0,0 +1,133 @@
+package gomirai
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/net/websocket"
+)
+
+// Mirai-api-http websocket 接口
+const (
+	// WSMessage 仅接收消息
+	WSMessage = "/message"
+	// WSEvent 仅接收事件
+	WSEvent = "/event"
+	// WSAll 接收消息及事件
+	WSAll = "/all"
+)
+
+var (
+	wsMinBackoff = time.Second
+	wsMaxBackoff = time.Minute
+	// wsStableTime 连接持续该时间后视为稳定，断开后重置退避时间
+	wsStableTime = 30 * time.Second
+)
+
+// SetWebSocket 使用 websocket 接收消息及事件
+// endpoint 为 WSMessage WSEvent WSAll 之一，为空时恢复使用 FetchMessages 轮询
+func (b *Bot) SetWebSocket(endpoint string) {
+	b.wsEndpoint = endpoint
+}
+
+// ListenWebSocket 通过 websocket 接收事件并放入 Chan
+// 连接断开后按指数退避自动重连
+func (b *Bot) ListenWebSocket() error {
+	return b.ListenWebSocketContext(context.Background())
+}
+
+// ListenWebSocketContext 同 ListenWebSocket，ctx 取消时断开连接并返回 nil
+// 每次连接失败或断开后均等待退避时间再重连，连接持续 wsStableTime 或收到事件后退避时间重置
+func (b *Bot) ListenWebSocketContext(ctx context.Context) error {
+	backoff := wsMinBackoff
+	for ctx.Err() == nil {
+		key := b.session()
+		ws, err := b.dialWebSocket(key)
+		if err != nil {
+			b.Logger.Warnln("WebSocket Dial Failed:", err, "retry in", backoff)
+		} else {
+			b.Logger.Infoln("WebSocket Connected", b.wsEndpoint)
+			start := time.Now()
+			if b.receiveWebSocket(ctx, ws, key) || time.Since(start) >= wsStableTime {
+				backoff = wsMinBackoff
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			b.Logger.Warnln("WebSocket Disconnected, retry in", backoff)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > wsMaxBackoff {
+			backoff = wsMaxBackoff
+		}
+	}
+	return nil
+}
+
+// receiveWebSocket 接收事件直至连接断开、Session 失效或 ctx 取消，返回是否收到过事件
+func (b *Bot) receiveWebSocket(ctx context.Context, ws *websocket.Conn, key string) bool {
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		ws.Close()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-done:
+		}
+	}()
+	delivered := false
+	for {
+		var data string
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			if ctx.Err() == nil {
+				b.Logger.Warnln("WebSocket Receive Failed:", err)
+			}
+			return delivered
+		}
+		event := adaptFrame(gjson.Parse(data))
+		if b.checkSession(ctx, key, event) {
+			return delivered
+		}
+		if !event.Get("type").Exists() {
+			continue
+		}
+		b.pushEvent(event)
+		delivered = true
+	}
+}
+
+// dialWebSocket 建立 websocket 连接
+func (b *Bot) dialWebSocket(key string) (*websocket.Conn, error) {
+	origin := b.Client.url
+	if !strings.Contains(origin, "://") {
+		origin = "http://" + origin
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + b.wsEndpoint
+	query := url.Values{"sessionKey": {key}}
+	if b.Client.version() == ProtocolV2 {
+		query.Set("verifyKey", b.Client.AuthKey)
+		query.Set("qq", strconv.FormatInt(b.QQ, 10))
+	}
+	u.RawQuery = query.Encode()
+	return websocket.Dial(u.String(), "", origin)
+}
@@ -0,0 +1,92 @@
+package gomirai
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/virzz/gomirai/message"
+)
+
+// newWebSocketBot 新建连接至 handler 的 Bot 及测试服务器
+func newWebSocketBot(t *testing.T, handler websocket.Handler) (*Bot, *httptest.Server) {
+	t.Helper()
+	s := httptest.NewServer(handler)
+	c := NewClient("test", s.URL, "key")
+	c.Protocol = ProtocolV1
+	b := &Bot{QQ: 1, SessionKey: "session", Client: c, Logger: c.Logger}
+	b.SetChannel(time.Second, 10)
+	b.SetWebSocket(WSAll)
+	return b, s
+}
+
+// setBackoff 缩短退避时间，返回恢复函数
+func setBackoff(min, max, stable time.Duration) func() {
+	oldMin, oldMax, oldStable := wsMinBackoff, wsMaxBackoff, wsStableTime
+	wsMinBackoff, wsMaxBackoff, wsStableTime = min, max, stable
+	return func() {
+		wsMinBackoff, wsMaxBackoff, wsStableTime = oldMin, oldMax, oldStable
+	}
+}
+
+func TestWebSocketBackoffAfterDisconnect(t *testing.T) {
+	defer setBackoff(50*time.Millisecond, 200*time.Millisecond, time.Minute)()
+	var conns int32
+	b, s := newWebSocketBot(t, func(ws *websocket.Conn) {
+		atomic.AddInt32(&conns, 1)
+		ws.Close()
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	if err := b.ListenWebSocketContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 50 + 100 + 200 + 200 毫秒的退避，最多连接 5 次
+	if n := atomic.LoadInt32(&conns); n < 2 || n > 5 {
+		t.Errorf("connections = %d, want 2..5", n)
+	}
+}
+
+func TestWebSocketDeliversEvents(t *testing.T) {
+	defer setBackoff(50*time.Millisecond, time.Second, time.Minute)()
+	b, s := newWebSocketBot(t, func(ws *websocket.Conn) {
+		if ws.Request().URL.Query().Get("sessionKey") != "session" {
+			t.Errorf("sessionKey = %q", ws.Request().URL.Query().Get("sessionKey"))
+		}
+		websocket.Message.Send(ws, `{"code":0}`)
+		websocket.Message.Send(ws, `{"type":"FriendMessage","messageChain":[{"type":"Plain","text":"hi"}],"sender":{"id":10001}}`)
+		ws.Close()
+	})
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.ListenWebSocketContext(ctx)
+		close(done)
+	}()
+	select {
+	case e := <-b.Chan:
+		m, ok := e.(*message.FriendMessage)
+		if !ok {
+			t.Fatalf("got %T, want *message.FriendMessage", e)
+		}
+		if m.Sender.ID != 10001 || m.MessageChain.PlainText() != "hi" {
+			t.Errorf("got sender %d text %q", m.Sender.ID, m.MessageChain.PlainText())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ListenWebSocketContext did not return after cancel")
+	}
+}